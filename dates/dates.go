@@ -1,6 +1,7 @@
 package dates
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"sort"
@@ -9,7 +10,28 @@ import (
 	"time"
 )
 
-const TimeFormat = "20060102"
+const (
+	TimeFormat  = "20060102"
+	ClockFormat = "15:04"
+)
+
+const (
+	MaxOccurrences = 366
+
+	maxWeekInterval  = 52
+	maxMonthInterval = 12
+)
+
+// maxSearchMonths ограничивает поиск даты для правил вида "m 5:5 2",
+// которые срабатывают лишь раз в несколько лет.
+const maxSearchMonths = 12 * 30
+
+var ErrRepeatEnded = errors.New("повторения по правилу закончились")
+
+type weekdayOrdinal struct {
+	n       int
+	weekday time.Weekday
+}
 
 func NextDate(now time.Time, date string, repeat string) (string, error) {
 	if repeat == "" {
@@ -20,8 +42,12 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	now = civilDate(now)
 
 	switch {
+	case IsRRule(repeat):
+		return nextRRule(now, parseDate, repeat)
+
 	case repeat == "y":
 		return addYear(now, parseDate)
 
@@ -41,8 +67,24 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 			return "", fmt.Errorf("неправильный формат")
 		}
 		repSplit := strings.Split(repeat, " ")
+		if len(repSplit) > 3 {
+			return "", fmt.Errorf("неправильный формат")
+		}
+		interval := 1
+		if len(repSplit) == 3 {
+			interval, err = parseInterval(repSplit[2], maxWeekInterval)
+			if err != nil {
+				return "", err
+			}
+		}
 		parseWeekDays := strings.Split(repSplit[1], ",")
-		return addWeekDay(now, parseDate, parseWeekDays)
+		return addWeekDay(now, parseDate, parseWeekDays, interval)
+
+	case strings.HasPrefix(repeat, "bm "):
+		return addMonthWorkDay(now, parseDate, strings.TrimPrefix(repeat, "bm "))
+
+	case strings.HasPrefix(repeat, "b "):
+		return addWorkDays(now, parseDate, strings.TrimPrefix(repeat, "b "))
 
 	case strings.HasPrefix(repeat, "m"):
 		return addMonthDay(now, parseDate, strings.TrimPrefix(repeat, "m "))
@@ -50,6 +92,57 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 	return "", fmt.Errorf("неподдерживаемый формат")
 }
 
+// Occurrences последовательно применяет NextDate, поэтому список совпадает
+// с датами, которые получит задача при отметке о выполнении в срок.
+func Occurrences(now time.Time, date string, repeat string, count int, until string) ([]string, error) {
+	if count < 0 || count > MaxOccurrences {
+		return nil, fmt.Errorf("количество дат должно быть от 1 до %d", MaxOccurrences)
+	}
+	if count == 0 {
+		if until == "" {
+			return nil, fmt.Errorf("не указано количество дат или конец периода")
+		}
+		count = MaxOccurrences
+	}
+	if until != "" {
+		if _, err := time.Parse(TimeFormat, until); err != nil {
+			return nil, fmt.Errorf("неправильный формат даты окончания периода")
+		}
+	}
+
+	// remaining - сколько повторений RRULE с COUNT осталось с даты date включительно.
+	remaining := 0
+	occurrences := []string{}
+	for len(occurrences) < count && remaining != 1 {
+		nextDate, err := NextDate(now, date, repeat)
+		if errors.Is(err, ErrRepeatEnded) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if until != "" && nextDate > until {
+			break
+		}
+		if remaining > 0 {
+			remaining--
+		} else if IsRRule(repeat) {
+			// COUNT отсчитывается от начала серии, а date сдвигается, поэтому дальше он считается здесь.
+			repeat, remaining, err = TakeRRuleCount(date, nextDate, repeat)
+			if err != nil {
+				return nil, err
+			}
+		}
+		occurrences = append(occurrences, nextDate)
+		date = nextDate
+		now, err = time.Parse(TimeFormat, nextDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return occurrences, nil
+}
+
 func addYear(currDate time.Time, date time.Time) (string, error) {
 	date = date.AddDate(1, 0, 0)
 	for date.Before(currDate) || date.Equal(currDate) {
@@ -70,31 +163,49 @@ func addDays(currDate time.Time, date time.Time, days int) (string, error) {
 	}
 }
 
-func addWeekDay(currDate time.Time, date time.Time, daysOfWeek []string) (string, error) {
-	comparDate := latestDate(currDate, date)
-	nextDate := comparDate
+func addWeekDay(currDate time.Time, date time.Time, daysOfWeek []string, interval int) (string, error) {
+	var weekdays []time.Weekday
 	for _, day := range daysOfWeek {
 		parseDay, err := strconv.Atoi(day)
 		if err != nil || parseDay < 1 || parseDay > 7 {
 			return "", fmt.Errorf("недопустимый формат дня недели")
 		}
-		targetDay := time.Weekday(parseDay % 7)
-		for nextDate.Weekday() != targetDay {
-			nextDate = nextDate.AddDate(0, 0, 1)
-		}
-		if nextDate.After(comparDate) {
-			return nextDate.Format(TimeFormat), nil
+		weekdays = append(weekdays, time.Weekday(parseDay%7))
+	}
+
+	comparDate := latestDate(currDate, date)
+	for i := 1; i <= 7*(interval+1); i++ {
+		nextDate := comparDate.AddDate(0, 0, i)
+		if !slices.Contains(weekdays, nextDate.Weekday()) || weeksBetween(date, nextDate)%interval != 0 {
+			continue
 		}
+		return nextDate.Format(TimeFormat), nil
 	}
 	return "", fmt.Errorf("нет подходящей даты")
 }
 
 func addMonthDay(currDate time.Time, date time.Time, monthRule string) (string, error) {
 	rules := strings.Split(monthRule, " ")
+	interval := 1
+	if last := rules[len(rules)-1]; strings.HasPrefix(last, "/") {
+		var err error
+		interval, err = parseInterval(last, maxMonthInterval)
+		if err != nil {
+			return "", err
+		}
+		rules = rules[:len(rules)-1]
+		if len(rules) > 1 {
+			return "", fmt.Errorf("интервал нельзя сочетать со списком месяцев")
+		}
+	}
 	if len(rules) < 1 || len(rules) > 2 {
 		return "", fmt.Errorf("неверный формат правила")
 	}
 
+	if strings.Contains(rules[0], ":") {
+		return addMonthWeekday(currDate, date, rules, interval)
+	}
+
 	comparDate := latestDate(currDate, date)
 	nextDate := comparDate
 
@@ -103,6 +214,10 @@ func addMonthDay(currDate time.Time, date time.Time, monthRule string) (string,
 		return "", err
 	}
 
+	if interval > 1 {
+		return addMonthInterval(comparDate, date, days, interval)
+	}
+
 	months := make([]time.Month, 12)
 	for i := range months {
 		months[i] = time.Month(i)
@@ -136,6 +251,135 @@ func addMonthDay(currDate time.Time, date time.Time, monthRule string) (string,
 	return nextDate.Format(TimeFormat), nil
 }
 
+func addMonthInterval(comparDate time.Time, date time.Time, days []int, interval int) (string, error) {
+	monthStart := time.Date(comparDate.Year(), comparDate.Month(), 1, 0, 0, 0, 0, comparDate.Location())
+	for i := 0; i < maxSearchMonths; i++ {
+		month := monthStart.AddDate(0, i, 0)
+		if monthsBetween(date, month)%interval != 0 {
+			continue
+		}
+		var nextDate time.Time
+		for _, day := range days {
+			monthDay, ok := dayOfMonth(month, day)
+			if !ok || !monthDay.After(comparDate) {
+				continue
+			}
+			if nextDate.IsZero() || monthDay.Before(nextDate) {
+				nextDate = monthDay
+			}
+		}
+		if !nextDate.IsZero() {
+			return nextDate.Format(TimeFormat), nil
+		}
+	}
+	return "", fmt.Errorf("нет подходящей даты")
+}
+
+func addMonthWeekday(currDate time.Time, date time.Time, rules []string, interval int) (string, error) {
+	ordinals, err := parseWeekdayOrdinals(rules[0])
+	if err != nil {
+		return "", err
+	}
+
+	months := []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	if len(rules) > 1 {
+		months, err = parseMonths(rules[1])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	comparDate := latestDate(currDate, date)
+	monthStart := time.Date(comparDate.Year(), comparDate.Month(), 1, 0, 0, 0, 0, comparDate.Location())
+	for i := 0; i < maxSearchMonths; i++ {
+		month := monthStart.AddDate(0, i, 0)
+		if !slices.Contains(months, month.Month()) || monthsBetween(date, month)%interval != 0 {
+			continue
+		}
+		var nextDate time.Time
+		for _, ordinal := range ordinals {
+			day, ok := nthWeekday(month, ordinal.n, ordinal.weekday)
+			if !ok || !day.After(comparDate) {
+				continue
+			}
+			if nextDate.IsZero() || day.Before(nextDate) {
+				nextDate = day
+			}
+		}
+		if !nextDate.IsZero() {
+			return nextDate.Format(TimeFormat), nil
+		}
+	}
+	return "", fmt.Errorf("нет подходящей даты")
+}
+
+func nthWeekday(month time.Time, n int, weekday time.Weekday) (time.Time, bool) {
+	if n < 0 {
+		lastDay := month.AddDate(0, 1, -1)
+		offset := (int(lastDay.Weekday()) - int(weekday) + 7) % 7
+		day := lastDay.AddDate(0, 0, (n+1)*7-offset)
+		return day, day.Month() == month.Month()
+	}
+	offset := (int(weekday) - int(month.Weekday()) + 7) % 7
+	day := month.AddDate(0, 0, offset+(n-1)*7)
+	if day.Month() != month.Month() {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+func dayOfMonth(month time.Time, day int) (time.Time, bool) {
+	lastDay := month.AddDate(0, 1, -1)
+	if day < 0 {
+		return lastDay.AddDate(0, 0, day+1), true
+	}
+	if day > lastDay.Day() {
+		return time.Time{}, false
+	}
+	return month.AddDate(0, 0, day-1), true
+}
+
+func parseInterval(s string, maxInterval int) (int, error) {
+	if !strings.HasPrefix(s, "/") {
+		return 0, fmt.Errorf("неверный формат интервала: %s", s)
+	}
+	interval, err := strconv.Atoi(strings.TrimPrefix(s, "/"))
+	if err != nil {
+		return 0, fmt.Errorf("неверный формат интервала: %s", s)
+	}
+	if interval < 1 || interval > maxInterval {
+		return 0, fmt.Errorf("недопустимое значение интервала: %d", interval)
+	}
+	return interval, nil
+}
+
+func parseWeekdayOrdinals(s string) ([]weekdayOrdinal, error) {
+	ordinalRules := strings.Split(s, ",")
+	var ordinals []weekdayOrdinal
+
+	for _, ordinalRule := range ordinalRules {
+		parts := strings.Split(ordinalRule, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("неверный формат правила: %s", ordinalRule)
+		}
+
+		n, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат номера недели: %s", parts[0])
+		}
+		if (n < 1 || n > 5) && n != -1 {
+			return nil, fmt.Errorf("недопустимый номер недели: %d", n)
+		}
+
+		weekday, err := strconv.Atoi(parts[1])
+		if err != nil || weekday < 1 || weekday > 7 {
+			return nil, fmt.Errorf("недопустимый формат дня недели")
+		}
+		ordinals = append(ordinals, weekdayOrdinal{n: n, weekday: time.Weekday(weekday % 7)})
+	}
+	return ordinals, nil
+}
+
 func parseDays(s string) ([]int, error) {
 	daysRules := strings.Split(s, ",")
 	var days []int
@@ -183,6 +427,20 @@ func parseMonths(s string) ([]time.Month, error) {
 	return months, nil
 }
 
+func weeksBetween(a, b time.Time) int {
+	mondayA := civilDate(a).AddDate(0, 0, -(int(a.Weekday())+6)%7)
+	mondayB := civilDate(b).AddDate(0, 0, -(int(b.Weekday())+6)%7)
+	return int(mondayB.Sub(mondayA).Hours()/24) / 7
+}
+
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
+
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 func latestDate(a, b time.Time) time.Time {
 	if a.After(b) {
 		return a
@@ -0,0 +1,127 @@
+package dates
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromRRule переводит RRULE события с датой date в правило повторения задачи.
+// COUNT и UNTIL возвращаются отдельно как число повторений и дата окончания.
+// Поддерживаемое планировщиком правило, которое нельзя записать короче,
+// возвращается как есть, с префиксом RRULE:.
+func FromRRule(rule string, date string) (repeat string, count int, until string, err error) {
+	parsed, err := parseRRule(rule)
+	if err != nil {
+		return "", 0, "", err
+	}
+	start, err := time.Parse(TimeFormat, date)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	repeat, ok := parsed.grammar(start)
+	if !ok {
+		return RRulePrefix + strings.TrimPrefix(rule, RRulePrefix), 0, "", nil
+	}
+	if !parsed.until.IsZero() {
+		until = parsed.until.Format(TimeFormat)
+	}
+	return repeat, parsed.count, until, nil
+}
+
+func (r rrule) grammar(start time.Time) (string, bool) {
+	if len(r.bySetPos) > 0 {
+		return "", false
+	}
+
+	switch r.freq {
+	case "DAILY":
+		if len(r.byDay) > 0 || len(r.byMonthDay) > 0 || len(r.byMonth) > 0 || r.interval > 400 {
+			return "", false
+		}
+		return "d " + strconv.Itoa(r.interval), true
+
+	case "WEEKLY":
+		if len(r.byMonthDay) > 0 || len(r.byMonth) > 0 || r.interval > maxWeekInterval {
+			return "", false
+		}
+		weekdays := []int{isoWeekday(start.Weekday())}
+		if len(r.byDay) > 0 {
+			weekdays = nil
+			for _, day := range r.byDay {
+				weekdays = append(weekdays, isoWeekday(day.weekday))
+			}
+		}
+		return "w " + joinInts(weekdays) + intervalSuffix(r.interval), true
+
+	case "MONTHLY":
+		return r.monthGrammar(start)
+
+	case "YEARLY":
+		if len(r.byDay) > 0 || len(r.byMonthDay) > 0 || len(r.byMonth) > 0 || r.interval > 1 {
+			return "", false
+		}
+		if start.Month() == time.February && start.Day() == 29 {
+			return "", false
+		}
+		return "y", true
+	}
+	return "", false
+}
+
+func (r rrule) monthGrammar(start time.Time) (string, bool) {
+	if r.interval > maxMonthInterval || (len(r.byDay) > 0 && len(r.byMonthDay) > 0) {
+		return "", false
+	}
+
+	var rule string
+	if len(r.byDay) > 0 {
+		var ordinals []string
+		for _, day := range r.byDay {
+			if day.n == 0 || day.n < -1 {
+				return "", false
+			}
+			ordinals = append(ordinals, strconv.Itoa(day.n)+":"+strconv.Itoa(isoWeekday(day.weekday)))
+		}
+		rule = "m " + strings.Join(ordinals, ",")
+	} else {
+		// Правило "m" по дням месяца не сочетает интервал со списком месяцев.
+		if r.interval > 1 && len(r.byMonth) > 0 {
+			return "", false
+		}
+		days := r.byMonthDay
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+		for _, day := range days {
+			if day < -2 {
+				return "", false
+			}
+		}
+		rule = "m " + joinInts(days)
+	}
+
+	if len(r.byMonth) > 0 {
+		var months []int
+		for _, month := range r.byMonth {
+			months = append(months, int(month))
+		}
+		rule += " " + joinInts(months)
+	}
+	return rule + intervalSuffix(r.interval), true
+}
+
+func isoWeekday(weekday time.Weekday) int {
+	if weekday == time.Sunday {
+		return 7
+	}
+	return int(weekday)
+}
+
+func intervalSuffix(interval int) string {
+	if interval == 1 {
+		return ""
+	}
+	return " /" + strconv.Itoa(interval)
+}
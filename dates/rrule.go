@@ -0,0 +1,390 @@
+package dates
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RRulePrefix = "RRULE:"
+
+	untilFormat    = "20060102T150405Z"
+	untilFormatTZ  = "20060102T150405"
+	maxSearchYears = 30
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+type rruleDay struct {
+	n       int
+	weekday time.Weekday
+}
+
+type rrule struct {
+	freq       string
+	interval   int
+	byDay      []rruleDay
+	byMonthDay []int
+	byMonth    []time.Month
+	bySetPos   []int
+	count      int
+	until      time.Time
+}
+
+func IsRRule(repeat string) bool {
+	return strings.HasPrefix(repeat, RRulePrefix) || strings.HasPrefix(repeat, "FREQ=")
+}
+
+func nextRRule(currDate time.Time, date time.Time, repeat string) (string, error) {
+	rule, err := parseRRule(repeat)
+	if err != nil {
+		return "", err
+	}
+
+	comparDate := latestDate(currDate, date)
+	var count int
+	for period := 0; ; period++ {
+		periodStart := rule.periodStart(date, period)
+		if periodStart.Year() > comparDate.Year()+maxSearchYears {
+			break
+		}
+		for _, candidate := range rule.expand(date, periodStart) {
+			if candidate.Before(date) {
+				continue
+			}
+			if !rule.until.IsZero() && candidate.After(rule.until) {
+				return "", ErrRepeatEnded
+			}
+			count++
+			if rule.count > 0 && count > rule.count {
+				return "", ErrRepeatEnded
+			}
+			if candidate.After(comparDate) {
+				return candidate.Format(TimeFormat), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("нет подходящей даты")
+}
+
+// TakeRRuleCount убирает COUNT из правила RRULE серии, начатой в date, и возвращает,
+// сколько повторений осталось с даты from включительно. COUNT считается от начала серии,
+// поэтому задача, дата которой переносится при каждом выполнении, хранит остаток отдельно.
+// Для правила без COUNT возвращается само правило и 0.
+func TakeRRuleCount(date string, from string, repeat string) (string, int, error) {
+	rule, err := parseRRule(repeat)
+	if err != nil {
+		return "", 0, err
+	}
+	if rule.count == 0 {
+		return repeat, 0, nil
+	}
+	start, err := time.Parse(TimeFormat, date)
+	if err != nil {
+		return "", 0, err
+	}
+	end, err := time.Parse(TimeFormat, from)
+	if err != nil {
+		return "", 0, err
+	}
+
+	remaining := rule.count - rule.countBefore(start, end)
+	if remaining < 1 {
+		return "", 0, ErrRepeatEnded
+	}
+	prefix := ""
+	if strings.HasPrefix(repeat, RRulePrefix) {
+		prefix = RRulePrefix
+	}
+	parts := strings.Split(strings.TrimPrefix(repeat, RRulePrefix), ";")
+	parts = slices.DeleteFunc(parts, func(part string) bool {
+		return strings.HasPrefix(part, "COUNT=")
+	})
+	return prefix + strings.Join(parts, ";"), remaining, nil
+}
+
+// countBefore считает повторения серии, начатой в dtStart, до даты end.
+func (r rrule) countBefore(dtStart time.Time, end time.Time) int {
+	var count int
+	for period := 0; ; period++ {
+		periodStart := r.periodStart(dtStart, period)
+		if periodStart.Year() > end.Year()+maxSearchYears {
+			return count
+		}
+		for _, candidate := range r.expand(dtStart, periodStart) {
+			if candidate.Before(dtStart) {
+				continue
+			}
+			if !candidate.Before(end) {
+				return count
+			}
+			count++
+		}
+	}
+}
+
+func parseRRule(repeat string) (rrule, error) {
+	rule := rrule{interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(repeat, RRulePrefix), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return rrule{}, fmt.Errorf("неверный формат правила RRULE: %s", part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				rule.freq = value
+			default:
+				return rrule{}, fmt.Errorf("неподдерживаемое значение FREQ: %s", value)
+			}
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(value)
+			if err != nil || rule.interval < 1 {
+				return rrule{}, fmt.Errorf("недопустимое значение INTERVAL: %s", value)
+			}
+		case "COUNT":
+			rule.count, err = strconv.Atoi(value)
+			if err != nil || rule.count < 1 {
+				return rrule{}, fmt.Errorf("недопустимое значение COUNT: %s", value)
+			}
+		case "UNTIL":
+			rule.until, err = parseUntil(value)
+			if err != nil {
+				return rrule{}, err
+			}
+		case "BYDAY":
+			rule.byDay, err = parseByDay(value)
+			if err != nil {
+				return rrule{}, err
+			}
+		case "BYMONTHDAY":
+			rule.byMonthDay, err = parseIntList(value, "BYMONTHDAY", 31)
+			if err != nil {
+				return rrule{}, err
+			}
+		case "BYMONTH":
+			months, err := parseMonths(value)
+			if err != nil {
+				return rrule{}, err
+			}
+			rule.byMonth = months
+		case "BYSETPOS":
+			rule.bySetPos, err = parseIntList(value, "BYSETPOS", 366)
+			if err != nil {
+				return rrule{}, err
+			}
+		case "WKST":
+			if value != "MO" {
+				return rrule{}, fmt.Errorf("поддерживается только WKST=MO")
+			}
+		default:
+			return rrule{}, fmt.Errorf("неподдерживаемая часть правила RRULE: %s", key)
+		}
+	}
+
+	if rule.freq == "" {
+		return rrule{}, fmt.Errorf("в правиле RRULE не указан FREQ")
+	}
+	if rule.count > 0 && !rule.until.IsZero() {
+		return rrule{}, fmt.Errorf("COUNT и UNTIL не могут использоваться вместе")
+	}
+	for _, day := range rule.byDay {
+		if day.n == 0 {
+			continue
+		}
+		if rule.freq != "MONTHLY" && rule.freq != "YEARLY" {
+			return rrule{}, fmt.Errorf("номер дня недели в BYDAY допустим только для MONTHLY и YEARLY")
+		}
+		if rule.freq == "YEARLY" && len(rule.byMonth) == 0 {
+			return rrule{}, fmt.Errorf("номер дня недели в BYDAY для YEARLY требует BYMONTH")
+		}
+	}
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{TimeFormat, untilFormat, untilFormatTZ} {
+		until, err := time.Parse(layout, value)
+		if err == nil {
+			return until, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неверный формат UNTIL: %s", value)
+}
+
+func parseByDay(value string) ([]rruleDay, error) {
+	var days []rruleDay
+	for _, dayRule := range strings.Split(value, ",") {
+		if len(dayRule) < 2 {
+			return nil, fmt.Errorf("неверный формат BYDAY: %s", dayRule)
+		}
+		weekday, ok := rruleWeekdays[dayRule[len(dayRule)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("неверный формат BYDAY: %s", dayRule)
+		}
+		var n int
+		if prefix := dayRule[:len(dayRule)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("неверный формат BYDAY: %s", dayRule)
+			}
+		}
+		days = append(days, rruleDay{n: n, weekday: weekday})
+	}
+	return days, nil
+}
+
+func parseIntList(value string, name string, limit int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		parsed, err := strconv.Atoi(item)
+		if err != nil || parsed == 0 || parsed < -limit || parsed > limit {
+			return nil, fmt.Errorf("недопустимое значение %s: %s", name, item)
+		}
+		values = append(values, parsed)
+	}
+	return values, nil
+}
+
+func (r rrule) periodStart(dtStart time.Time, period int) time.Time {
+	step := period * r.interval
+	switch r.freq {
+	case "DAILY":
+		return dtStart.AddDate(0, 0, step)
+	case "WEEKLY":
+		offset := (int(dtStart.Weekday()) + 6) % 7
+		return dtStart.AddDate(0, 0, step*7-offset)
+	case "MONTHLY":
+		return time.Date(dtStart.Year(), dtStart.Month()+time.Month(step), 1, 0, 0, 0, 0, dtStart.Location())
+	default:
+		return time.Date(dtStart.Year()+step, 1, 1, 0, 0, 0, 0, dtStart.Location())
+	}
+}
+
+func (r rrule) expand(dtStart time.Time, periodStart time.Time) []time.Time {
+	var candidates []time.Time
+	switch r.freq {
+	case "DAILY":
+		candidates = []time.Time{periodStart}
+	case "WEEKLY":
+		if len(r.byDay) == 0 {
+			candidates = []time.Time{periodStart.AddDate(0, 0, (int(dtStart.Weekday())+6)%7)}
+		}
+		for _, day := range r.byDay {
+			candidates = append(candidates, periodStart.AddDate(0, 0, (int(day.weekday)+6)%7))
+		}
+	case "MONTHLY":
+		candidates = r.expandMonth(dtStart, periodStart)
+	default:
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{dtStart.Month()}
+			if len(r.byDay) > 0 || len(r.byMonthDay) > 0 {
+				months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			}
+		}
+		for _, month := range months {
+			monthStart := time.Date(periodStart.Year(), month, 1, 0, 0, 0, 0, periodStart.Location())
+			candidates = append(candidates, r.expandMonth(dtStart, monthStart)...)
+		}
+	}
+
+	candidates = slices.DeleteFunc(candidates, func(candidate time.Time) bool {
+		return !r.matches(candidate)
+	})
+	slices.SortFunc(candidates, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	candidates = slices.CompactFunc(candidates, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+	return r.applySetPos(candidates)
+}
+
+func (r rrule) expandMonth(dtStart time.Time, monthStart time.Time) []time.Time {
+	lastDay := monthStart.AddDate(0, 1, -1).Day()
+	var candidates []time.Time
+	switch {
+	case len(r.byMonthDay) > 0:
+		for _, day := range r.byMonthDay {
+			if day < 0 {
+				day = lastDay + day + 1
+			}
+			if 0 < day && day <= lastDay {
+				candidates = append(candidates, monthStart.AddDate(0, 0, day-1))
+			}
+		}
+	case len(r.byDay) > 0:
+		for _, day := range r.byDay {
+			if day.n != 0 {
+				if date, ok := nthWeekday(monthStart, day.n, day.weekday); ok {
+					candidates = append(candidates, date)
+				}
+				continue
+			}
+			for date, ok := nthWeekday(monthStart, 1, day.weekday); ok && date.Month() == monthStart.Month(); date = date.AddDate(0, 0, 7) {
+				candidates = append(candidates, date)
+			}
+		}
+	default:
+		if dtStart.Day() <= lastDay {
+			candidates = append(candidates, monthStart.AddDate(0, 0, dtStart.Day()-1))
+		}
+	}
+	return candidates
+}
+
+func (r rrule) matches(date time.Time) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, date.Month()) {
+		return false
+	}
+	if len(r.byMonthDay) > 0 {
+		lastDay := date.AddDate(0, 1, -date.Day()).Day()
+		if !slices.Contains(r.byMonthDay, date.Day()) && !slices.Contains(r.byMonthDay, date.Day()-lastDay-1) {
+			return false
+		}
+	}
+	if len(r.byDay) > 0 && (r.freq == "DAILY" || len(r.byMonthDay) > 0) {
+		return slices.ContainsFunc(r.byDay, func(day rruleDay) bool {
+			return day.weekday == date.Weekday()
+		})
+	}
+	return true
+}
+
+func (r rrule) applySetPos(candidates []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return candidates
+	}
+	var selected []time.Time
+	for _, pos := range r.bySetPos {
+		index := pos - 1
+		if pos < 0 {
+			index = len(candidates) + pos
+		}
+		if 0 <= index && index < len(candidates) {
+			selected = append(selected, candidates[index])
+		}
+	}
+	slices.SortFunc(selected, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	return slices.CompactFunc(selected, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+}
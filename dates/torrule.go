@@ -0,0 +1,140 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rruleWeekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ToRRule переводит правило повторения задачи с датой date в RRULE (без префикса).
+// ok=false означает, что правило нельзя выразить через RRULE: например,
+// правила по рабочим дням зависят от календаря праздников.
+func ToRRule(date string, repeat string) (rule string, ok bool) {
+	switch {
+	case IsRRule(repeat):
+		if _, err := parseRRule(repeat); err != nil {
+			return "", false
+		}
+		return strings.TrimPrefix(repeat, RRulePrefix), true
+
+	case repeat == "y":
+		// NextDate переносит 29 февраля на 1 марта, а RRULE пропускает невисокосные годы.
+		if strings.HasSuffix(date, "0229") {
+			return "", false
+		}
+		return "FREQ=YEARLY", true
+
+	case strings.HasPrefix(repeat, "d "):
+		days, err := strconv.Atoi(strings.TrimPrefix(repeat, "d "))
+		if err != nil || days < 1 || days > 400 {
+			return "", false
+		}
+		return withInterval("FREQ=DAILY", days), true
+
+	case strings.HasPrefix(repeat, "w "):
+		parts := strings.Split(repeat, " ")
+		if len(parts) > 3 {
+			return "", false
+		}
+		interval := 1
+		if len(parts) == 3 {
+			var err error
+			interval, err = parseInterval(parts[2], maxWeekInterval)
+			if err != nil {
+				return "", false
+			}
+		}
+		var byDay []string
+		for _, day := range strings.Split(parts[1], ",") {
+			weekday, err := strconv.Atoi(day)
+			if err != nil || weekday < 1 || weekday > 7 {
+				return "", false
+			}
+			byDay = append(byDay, rruleWeekdayNames[weekday%7])
+		}
+		return withInterval("FREQ=WEEKLY", interval) + ";BYDAY=" + strings.Join(byDay, ",") + ";WKST=MO", true
+
+	case strings.HasPrefix(repeat, "m "):
+		return monthToRRule(strings.TrimPrefix(repeat, "m "))
+	}
+	return "", false
+}
+
+func monthToRRule(monthRule string) (string, bool) {
+	rules := strings.Split(monthRule, " ")
+	interval := 1
+	if last := rules[len(rules)-1]; strings.HasPrefix(last, "/") {
+		var err error
+		interval, err = parseInterval(last, maxMonthInterval)
+		if err != nil {
+			return "", false
+		}
+		rules = rules[:len(rules)-1]
+	}
+	if len(rules) < 1 || len(rules) > 2 {
+		return "", false
+	}
+
+	rule := withInterval("FREQ=MONTHLY", interval)
+	if strings.Contains(rules[0], ":") {
+		ordinals, err := parseWeekdayOrdinals(rules[0])
+		if err != nil {
+			return "", false
+		}
+		var byDay []string
+		for _, ordinal := range ordinals {
+			byDay = append(byDay, strconv.Itoa(ordinal.n)+rruleWeekdayNames[ordinal.weekday])
+		}
+		rule += ";BYDAY=" + strings.Join(byDay, ",")
+	} else {
+		// NextDate не допускает интервал вместе со списком месяцев.
+		if interval > 1 && len(rules) > 1 {
+			return "", false
+		}
+		days, err := parseDays(rules[0])
+		if err != nil {
+			return "", false
+		}
+		rule += ";BYMONTHDAY=" + joinInts(days)
+	}
+
+	if len(rules) > 1 {
+		months, err := parseMonths(rules[1])
+		if err != nil {
+			return "", false
+		}
+		var values []int
+		for _, month := range months {
+			values = append(values, int(month))
+		}
+		rule += ";BYMONTH=" + joinInts(values)
+	}
+	return rule, true
+}
+
+// RRuleUntil возвращает значение UNTIL для последнего дня периода until.
+func RRuleUntil(until string, loc *time.Location) (string, error) {
+	day, err := time.ParseInLocation(TimeFormat, until, loc)
+	if err != nil {
+		return "", fmt.Errorf("неправильный формат даты окончания повторений")
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Second).UTC().Format(untilFormat), nil
+}
+
+func withInterval(freq string, interval int) string {
+	if interval == 1 {
+		return freq
+	}
+	return freq + ";INTERVAL=" + strconv.Itoa(interval)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, strconv.Itoa(value))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,111 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxWorkDays     = 400
+	maxWorkDayIndex = 23
+)
+
+type Calendar interface {
+	IsHoliday(date time.Time) bool
+}
+
+type noHolidays struct{}
+
+func (noHolidays) IsHoliday(time.Time) bool {
+	return false
+}
+
+var calendar Calendar = noHolidays{}
+
+// SetCalendar задаёт календарь праздников, который учитывают правила "b" и "bm".
+func SetCalendar(c Calendar) {
+	calendar = c
+}
+
+func IsWorkingDay(date time.Time) bool {
+	weekday := date.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday && !calendar.IsHoliday(date)
+}
+
+func addWorkDays(currDate time.Time, date time.Time, rule string) (string, error) {
+	days, err := strconv.Atoi(rule)
+	if err != nil {
+		return "", fmt.Errorf("неверный формат количества рабочих дней: %s", rule)
+	}
+	if days < 1 || days > maxWorkDays {
+		return "", fmt.Errorf("значение рабочих дней не входит в допустимый интервал")
+	}
+
+	date = skipWorkDays(date, days)
+	for date.Before(currDate) || date.Equal(currDate) {
+		date = skipWorkDays(date, days)
+	}
+	return date.Format(TimeFormat), nil
+}
+
+func skipWorkDays(date time.Time, days int) time.Time {
+	for days > 0 {
+		date = date.AddDate(0, 0, 1)
+		if IsWorkingDay(date) {
+			days--
+		}
+	}
+	return date
+}
+
+func addMonthWorkDay(currDate time.Time, date time.Time, rule string) (string, error) {
+	var indexes []int
+	for _, indexRule := range strings.Split(rule, ",") {
+		index, err := strconv.Atoi(indexRule)
+		if err != nil {
+			return "", fmt.Errorf("неверный формат рабочего дня: %s", indexRule)
+		}
+		if (index < 1 || index > maxWorkDayIndex) && index != -1 {
+			return "", fmt.Errorf("недопустимое значение рабочего дня: %d", index)
+		}
+		indexes = append(indexes, index)
+	}
+
+	comparDate := latestDate(currDate, date)
+	monthStart := time.Date(comparDate.Year(), comparDate.Month(), 1, 0, 0, 0, 0, comparDate.Location())
+	for i := 0; i < maxSearchMonths; i++ {
+		workDays := monthWorkDays(monthStart.AddDate(0, i, 0))
+		var nextDate time.Time
+		for _, index := range indexes {
+			if index > len(workDays) || len(workDays) == 0 {
+				continue
+			}
+			workDay := workDays[len(workDays)-1]
+			if index > 0 {
+				workDay = workDays[index-1]
+			}
+			if !workDay.After(comparDate) {
+				continue
+			}
+			if nextDate.IsZero() || workDay.Before(nextDate) {
+				nextDate = workDay
+			}
+		}
+		if !nextDate.IsZero() {
+			return nextDate.Format(TimeFormat), nil
+		}
+	}
+	return "", fmt.Errorf("нет подходящей даты")
+}
+
+func monthWorkDays(month time.Time) []time.Time {
+	var workDays []time.Time
+	for day := month; day.Month() == month.Month(); day = day.AddDate(0, 0, 1) {
+		if IsWorkingDay(day) {
+			workDays = append(workDays, day)
+		}
+	}
+	return workDays
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+func HandleCreateAPIToken(tokens *service.APITokenService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if !requireSession(w, r) {
+			return
+		}
+
+		var token models.APIToken
+		err := json.NewDecoder(r.Body).Decode(&token)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		token, err = tokens.Create(middleware.UserID(r.Context()), token)
+		if err != nil {
+			log.Printf("ошибка создания токена: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(token)
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleGetAPITokens(tokens *service.APITokenService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if !requireSession(w, r) {
+			return
+		}
+
+		list, err := tokens.List(middleware.UserID(r.Context()))
+		if err != nil {
+			log.Printf("ошибка получения токенов: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if list == nil {
+			list = []models.APIToken{}
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"tokens": list})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleRevokeAPIToken(tokens *service.APITokenService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if !requireSession(w, r) {
+			return
+		}
+		query := r.URL.Query()
+
+		if !query.Has("id") {
+			log.Println("отсутствует идентификатор")
+			http.Error(w, `{"error": "Отсутствует идентификатор"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := tokens.Revoke(middleware.UserID(r.Context()), query.Get("id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+// requireSession не даёт управлять API-токенами без входа по паролю,
+// в том числе с помощью самих API-токенов.
+func requireSession(w http.ResponseWriter, r *http.Request) bool {
+	if middleware.SessionID(r.Context()) == "" {
+		log.Println("управление токенами доступно только после входа")
+		http.Error(w, `{"error": "Управление токенами доступно только после входа"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -2,19 +2,56 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 
-	"github.com/Yandex-Practicum/final-project/jwt"
+	"github.com/Yandex-Practicum/final-project/middleware"
 	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
 )
 
-var password = os.Getenv("TODO_PASSWORD")
+func HangdleLogin(users *service.UserService, sessions *service.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		var login models.Login
+		err := json.NewDecoder(r.Body).Decode(&login)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		var userID int64
+		if login.Login == "" {
+			password := os.Getenv("TODO_PASSWORD")
+			if len(password) == 0 {
+				log.Println("вход по общему паролю не настроен")
+				http.Error(w, `{"error": "Вход без логина отключён"}`, http.StatusForbidden)
+				return
+			}
+			if login.Password != password {
+				log.Println("некорректные данные")
+				http.Error(w, `{"error": "Некорректные данные"}`, http.StatusForbidden)
+				return
+			}
+		} else {
+			userID, err = users.Authenticate(login)
+			if err != nil {
+				log.Println(err.Error())
+				http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusForbidden)
+				return
+			}
+		}
+
+		writeToken(w, sessions, userID)
+	}
+}
 
-func HangdleLogin(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	if len(password) > 0 {
+func HandleRegister(users *service.UserService, sessions *service.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		var login models.Login
 		err := json.NewDecoder(r.Body).Decode(&login)
 		if err != nil {
@@ -22,20 +59,83 @@ func HangdleLogin(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
 			return
 		}
-		if login.Password != password {
-			log.Println("некорректные данные")
-			http.Error(w, `{"error": "Некорректные данные"}`, http.StatusForbidden)
+
+		userID, err := users.Register(login)
+		if err != nil {
+			log.Printf("ошибка регистрации: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		writeToken(w, sessions, userID)
+	}
+}
+
+func HandleRefresh(sessions *service.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		var request models.RefreshRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		newToken, err := sessions.Refresh(request.RefreshToken)
+		if errors.Is(err, service.ErrInvalidRefresh) {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusUnauthorized)
 			return
 		}
-		newToken, err := jwt.JWTCreate()
 		if err != nil {
 			log.Println(err.Error())
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
 			return
 		}
+
 		err = json.NewEncoder(w).Encode(newToken)
 		if err != nil {
 			log.Printf("не удалось закодировать ответ: %v", err)
 		}
 	}
 }
+
+func HandleSignout(sessions *service.SessionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		sessionID := middleware.SessionID(r.Context())
+		if sessionID == "" {
+			log.Println("нет активной сессии")
+			http.Error(w, `{"error": "Нет активной сессии"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := sessions.Revoke(sessionID)
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "token", Path: "/", MaxAge: -1})
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func writeToken(w http.ResponseWriter, sessions *service.SessionService, userID int64) {
+	newToken, err := sessions.Start(userID)
+	if err != nil {
+		log.Println(err.Error())
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	err = json.NewEncoder(w).Encode(newToken)
+	if err != nil {
+		log.Printf("не удалось закодировать ответ: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+// HandleExportTasks выгружает все задачи в JSON (по умолчанию) или, с ?format=csv, в CSV.
+func HandleExportTasks(backupService *service.BackupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			log.Printf("неизвестный формат выгрузки: %s", format)
+			http.Error(w, `{"error": "Формат должен быть json или csv"}`, http.StatusBadRequest)
+			return
+		}
+
+		tasks, err := backupService.ExportTasks(middleware.UserID(r.Context()))
+		if err != nil {
+			log.Printf("ошибка выгрузки задач: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="tasks.csv"`)
+			err = service.WriteTasksCSV(w, tasks)
+		} else {
+			w.Header().Set("Content-Disposition", `attachment; filename="tasks.json"`)
+			err = json.NewEncoder(w).Encode(map[string]interface{}{"tasks": tasks})
+		}
+		if err != nil {
+			log.Printf("не удалось записать выгрузку: %v", err)
+		}
+	}
+}
+
+func HandleImportTasks(backupService *service.BackupService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		body, err := uploadBody(w, r)
+		if err != nil {
+			log.Printf("ошибка чтения файла: %v", err)
+			http.Error(w, `{"error": "Ошибка чтения файла"}`, http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+
+		result, err := backupService.ImportTasks(middleware.UserID(r.Context()), body, query.Get("format"), query.Get("mode"))
+		if err != nil {
+			log.Printf("ошибка импорта задач: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(result)
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
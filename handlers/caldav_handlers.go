@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+const (
+	CalDAVRoot  = "/caldav/"
+	CalDAVTasks = CalDAVRoot + "tasks/"
+
+	caldavContentType = "text/calendar; charset=utf-8; component=vtodo"
+)
+
+// calendarReport - тело запроса REPORT: calendar-query или calendar-multiget.
+type calendarReport struct {
+	XMLName xml.Name
+	Hrefs   []string     `xml:"DAV: href"`
+	Filters []compFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+type compFilter struct {
+	Name    string       `xml:"name,attr"`
+	Filters []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// HandleCalDAV обслуживает коллекцию задач CalDAV: корень /caldav/ служит и принципалом,
+// и домашним каталогом календарей, задачи лежат в /caldav/tasks/.
+func HandleCalDAV(service *service.CalDAVService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodOptions:
+			w.Header().Set("DAV", "1, 3, calendar-access")
+			w.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT, GET, HEAD, PUT, DELETE")
+			w.WriteHeader(http.StatusOK)
+		case "PROPFIND":
+			caldavPropfind(w, r, service)
+		case "REPORT":
+			caldavReport(w, r, service)
+		case http.MethodGet, http.MethodHead:
+			caldavGet(w, r, service)
+		case http.MethodPut:
+			caldavPut(w, r, service)
+		case http.MethodDelete:
+			caldavDelete(w, r, service)
+		default:
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func caldavPropfind(w http.ResponseWriter, r *http.Request, caldav *service.CalDAVService) {
+	userID := middleware.UserID(r.Context())
+	depth := r.Header.Get("Depth")
+	var b strings.Builder
+
+	switch r.URL.Path {
+	case CalDAVRoot, strings.TrimSuffix(CalDAVRoot, "/"):
+		writeResponse(&b, CalDAVRoot, rootProps())
+		if depth == "0" {
+			break
+		}
+		ctag, err := caldav.CTag(userID)
+		if err != nil {
+			caldavError(w, err)
+			return
+		}
+		writeResponse(&b, CalDAVTasks, collectionProps(ctag))
+
+	case CalDAVTasks, strings.TrimSuffix(CalDAVTasks, "/"):
+		ctag, err := caldav.CTag(userID)
+		if err != nil {
+			caldavError(w, err)
+			return
+		}
+		writeResponse(&b, CalDAVTasks, collectionProps(ctag))
+		if depth == "0" {
+			break
+		}
+		items, err := caldav.GetItems(userID)
+		if err != nil {
+			caldavError(w, err)
+			return
+		}
+		for _, item := range items {
+			writeResponse(&b, itemHref(item.Name), itemProps(item, false))
+		}
+
+	default:
+		name, ok := itemName(r.URL.Path)
+		if !ok {
+			http.Error(w, "Ресурс не найден", http.StatusNotFound)
+			return
+		}
+		item, err := caldav.GetItem(userID, name)
+		if err != nil {
+			caldavError(w, err)
+			return
+		}
+		writeResponse(&b, itemHref(item.Name), itemProps(item, false))
+	}
+	writeMultistatus(w, b.String())
+}
+
+func caldavReport(w http.ResponseWriter, r *http.Request, caldav *service.CalDAVService) {
+	userID := middleware.UserID(r.Context())
+	var report calendarReport
+	err := xml.NewDecoder(r.Body).Decode(&report)
+	if err != nil {
+		log.Printf("ошибка разбора REPORT: %v", err)
+		http.Error(w, "Ошибка разбора XML", http.StatusBadRequest)
+		return
+	}
+
+	var b strings.Builder
+	switch report.XMLName.Local {
+	case "calendar-query":
+		if !queriesTodos(report.Filters) {
+			break
+		}
+		items, err := caldav.GetItems(userID)
+		if err != nil {
+			caldavError(w, err)
+			return
+		}
+		for _, item := range items {
+			writeResponse(&b, itemHref(item.Name), itemProps(item, true))
+		}
+
+	case "calendar-multiget":
+		for _, href := range report.Hrefs {
+			name, ok := itemName(href)
+			var item service.CalDAVItem
+			if ok {
+				item, err = caldav.GetItem(userID, name)
+			}
+			if !ok || err != nil {
+				b.WriteString("<d:response><d:href>" + escapeXML(href) + "</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>")
+				continue
+			}
+			writeResponse(&b, itemHref(item.Name), itemProps(item, true))
+		}
+
+	default:
+		http.Error(w, "Отчёт не поддерживается", http.StatusForbidden)
+		return
+	}
+	writeMultistatus(w, b.String())
+}
+
+func caldavGet(w http.ResponseWriter, r *http.Request, caldav *service.CalDAVService) {
+	name, ok := itemName(r.URL.Path)
+	if !ok {
+		http.Error(w, "Ресурс не найден", http.StatusNotFound)
+		return
+	}
+	item, err := caldav.GetItem(middleware.UserID(r.Context()), name)
+	if err != nil {
+		caldavError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", caldavContentType)
+	w.Header().Set("ETag", `"`+item.ETag+`"`)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, err = io.WriteString(w, item.Data)
+	if err != nil {
+		log.Printf("не удалось записать задачу: %v", err)
+	}
+}
+
+func caldavPut(w http.ResponseWriter, r *http.Request, caldav *service.CalDAVService) {
+	name, ok := itemName(r.URL.Path)
+	if !ok {
+		http.Error(w, "Ресурс не найден", http.StatusNotFound)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	created, etag, err := caldav.PutItem(middleware.UserID(r.Context()), name, r.Body,
+		strings.Trim(r.Header.Get("If-Match"), `"`), r.Header.Get("If-None-Match"))
+	if err != nil {
+		caldavError(w, err)
+		return
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", `"`+etag+`"`)
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func caldavDelete(w http.ResponseWriter, r *http.Request, caldav *service.CalDAVService) {
+	name, ok := itemName(r.URL.Path)
+	if !ok {
+		http.Error(w, "Ресурс не найден", http.StatusNotFound)
+		return
+	}
+	err := caldav.DeleteItem(middleware.UserID(r.Context()), name, strings.Trim(r.Header.Get("If-Match"), `"`))
+	if err != nil {
+		caldavError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func caldavError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, service.ErrTaskBlocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrItemClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Printf("ошибка CalDAV: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// queriesTodos проверяет, что calendar-query запрашивает VTODO: других компонентов в коллекции нет.
+func queriesTodos(filters []compFilter) bool {
+	for _, calendar := range filters {
+		for _, component := range calendar.Filters {
+			if !strings.EqualFold(component.Name, "VTODO") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// itemName возвращает имя ресурса задачи из пути или полного адреса.
+func itemName(href string) (string, bool) {
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	name, ok := strings.CutPrefix(href, CalDAVTasks)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+func itemHref(name string) string {
+	return CalDAVTasks + url.PathEscape(name)
+}
+
+func rootProps() string {
+	return "<d:resourcetype><d:collection/><d:principal/></d:resourcetype>" +
+		"<d:displayname>Планировщик задач</d:displayname>" +
+		"<d:current-user-principal><d:href>" + CalDAVRoot + "</d:href></d:current-user-principal>" +
+		"<d:principal-URL><d:href>" + CalDAVRoot + "</d:href></d:principal-URL>" +
+		"<c:calendar-home-set><d:href>" + CalDAVRoot + "</d:href></c:calendar-home-set>"
+}
+
+func collectionProps(ctag string) string {
+	return "<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>" +
+		"<d:displayname>Задачи</d:displayname>" +
+		"<d:current-user-principal><d:href>" + CalDAVRoot + "</d:href></d:current-user-principal>" +
+		`<c:supported-calendar-component-set><c:comp name="VTODO"/></c:supported-calendar-component-set>` +
+		"<cs:getctag>" + ctag + "</cs:getctag>"
+}
+
+func itemProps(item service.CalDAVItem, data bool) string {
+	props := "<d:resourcetype/>" +
+		"<d:getcontenttype>" + caldavContentType + "</d:getcontenttype>" +
+		`<d:getetag>"` + item.ETag + `"</d:getetag>`
+	if data {
+		props += "<c:calendar-data>" + escapeXML(item.Data) + "</c:calendar-data>"
+	}
+	return props
+}
+
+func writeResponse(b *strings.Builder, href string, props string) {
+	b.WriteString("<d:response><d:href>" + escapeXML(href) + "</d:href><d:propstat><d:prop>" + props +
+		"</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>")
+}
+
+func writeMultistatus(w http.ResponseWriter, responses string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, err := io.WriteString(w, xml.Header+
+		`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`+
+		responses+"</d:multistatus>")
+	if err != nil {
+		log.Printf("не удалось записать ответ: %v", err)
+	}
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
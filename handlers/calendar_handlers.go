@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+// HandleCalendarFeed отдаёт задачи файлом .ics, с ?todo=true - задачами VTODO вместо событий.
+func HandleCalendarFeed(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calendar, err := service.ExportCalendar(middleware.UserID(r.Context()), r.URL.Query().Get("todo") == "true")
+		if err != nil {
+			log.Printf("ошибка экспорта календаря: %v", err)
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=UTF-8")
+		w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+		err = calendar.Encode(w)
+		if err != nil {
+			log.Printf("не удалось записать календарь: %v", err)
+		}
+	}
+}
+
+func HandleImportCalendar(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		body, err := uploadBody(w, r)
+		if err != nil {
+			log.Printf("ошибка чтения файла: %v", err)
+			http.Error(w, `{"error": "Ошибка чтения файла"}`, http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+
+		result, err := service.ImportCalendar(middleware.UserID(r.Context()), body)
+		if err != nil {
+			log.Printf("ошибка импорта календаря: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(result)
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
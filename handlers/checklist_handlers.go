@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+func HandleAddChecklistItem(service *service.ChecklistService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var item models.ChecklistItem
+		err := json.NewDecoder(r.Body).Decode(&item)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		id, err := service.AddItem(middleware.UserID(r.Context()), item)
+		if err != nil {
+			log.Printf("ошибка добавления пункта чек-листа: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleGetChecklist(service *service.ChecklistService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		if !query.Has("task_id") {
+			log.Println("отсутствует идентификатор задачи")
+			http.Error(w, `{"error": "Отсутствует идентификатор задачи"}`, http.StatusBadRequest)
+			return
+		}
+
+		items, err := service.GetItems(middleware.UserID(r.Context()), query.Get("task_id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if items == nil {
+			items = []models.ChecklistItem{}
+		}
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleEditChecklistItem(service *service.ChecklistService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var item models.ChecklistItem
+		err := json.NewDecoder(r.Body).Decode(&item)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		if item.Id == "" {
+			log.Println("не указан идентификатор пункта чек-листа")
+			http.Error(w, `{"error": "Не указан идентификатор пункта чек-листа"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = service.EditItem(middleware.UserID(r.Context()), item)
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleDeleteChecklistItem(service *service.ChecklistService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		if !query.Has("id") {
+			log.Println("отсутствует идентификатор")
+			http.Error(w, `{"error": "Отсутствует идентификатор"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := service.DeleteItem(middleware.UserID(r.Context()), query.Get("id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+// HandleCheckChecklistItem отмечает пункт выполненным (done=true) или снимает отметку.
+func HandleCheckChecklistItem(service *service.ChecklistService, done bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		if !query.Has("id") {
+			log.Println("отсутствует идентификатор")
+			http.Error(w, `{"error": "Отсутствует идентификатор"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := service.CheckItem(middleware.UserID(r.Context()), query.Get("id"), done)
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleReorderChecklist(service *service.ChecklistService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var order models.ChecklistOrder
+		err := json.NewDecoder(r.Body).Decode(&order)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = service.Reorder(middleware.UserID(r.Context()), order)
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+func HandleTaskUndone(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		if !query.Has("id") {
+			log.Println("отсутствует идентификатор")
+			http.Error(w, `{"error": "Отсутствует идентификатор"}`, http.StatusBadRequest)
+			return
+		}
+
+		completion, err := service.UndoneTask(middleware.UserID(r.Context()), query.Get("id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"id": completion.TaskId, "date": completion.Date})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleGetCompletions(taskService *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		values := r.URL.Query()
+		query := models.CompletionQuery{
+			TaskId: values.Get("task_id"),
+			From:   values.Get("from"),
+			To:     values.Get("to"),
+			Limit:  LimitTasks,
+		}
+		if values.Has("limit") {
+			limit, err := strconv.Atoi(values.Get("limit"))
+			if err != nil || limit < 1 || limit > MaxLimitTasks {
+				log.Printf("неправильное число записей: %s", values.Get("limit"))
+				http.Error(w, `{"error": "Неправильное число записей"}`, http.StatusBadRequest)
+				return
+			}
+			query.Limit = limit
+		}
+
+		completions, err := taskService.GetCompletions(middleware.UserID(r.Context()), query)
+		if errors.Is(err, service.ErrInvalidQuery) {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("ошибка получения истории выполнения: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if completions == nil {
+			completions = []models.Completion{}
+		}
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"completions": completions})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
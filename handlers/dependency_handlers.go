@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+func HandleAddDependency(service *service.DependencyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var dependency models.Dependency
+		err := json.NewDecoder(r.Body).Decode(&dependency)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = service.AddDependency(middleware.UserID(r.Context()), dependency)
+		if err != nil {
+			log.Printf("ошибка добавления зависимости: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleDeleteDependency(service *service.DependencyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		for _, param := range []string{"task_id", "depends_on"} {
+			if !query.Has(param) {
+				log.Printf("пропущен обязательный параметр: %s", param)
+				http.Error(w, `{"error": "Пропущен обязательный параметр"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		err := service.DeleteDependency(middleware.UserID(r.Context()), models.Dependency{
+			TaskId:    query.Get("task_id"),
+			DependsOn: query.Get("depends_on"),
+		})
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
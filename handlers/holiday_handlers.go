@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+const maxUploadSize = 10 << 20
+
+func HandleAddHoliday(service *service.HolidayService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var holiday models.Holiday
+		err := json.NewDecoder(r.Body).Decode(&holiday)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		id, err := service.AddHoliday(holiday)
+		if err != nil {
+			log.Printf("ошибка добавления праздника: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleEditHoliday(service *service.HolidayService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		var holiday models.Holiday
+		err := json.NewDecoder(r.Body).Decode(&holiday)
+		if err != nil {
+			log.Printf("ошибка десериализации JSON: %v", err)
+			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = service.EditHoliday(holiday)
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleGetHolidays(service *service.HolidayService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		holidays, err := service.GetHolidays()
+		if err != nil {
+			log.Printf("ошибка получения праздников: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if holidays == nil {
+			holidays = []models.Holiday{}
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"holidays": holidays})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleDeleteHoliday(service *service.HolidayService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		if !query.Has("id") {
+			log.Println("отсутствует идентификатор")
+			http.Error(w, `{"error": "Отсутствует идентификатор"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := service.DeleteHoliday(query.Get("id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleImportHolidays(service *service.HolidayService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		body, err := uploadBody(w, r)
+		if err != nil {
+			log.Printf("ошибка чтения файла: %v", err)
+			http.Error(w, `{"error": "Ошибка чтения файла"}`, http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+
+		result, err := service.ImportHolidays(body)
+		if err != nil {
+			log.Printf("ошибка импорта праздников: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(result)
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+// uploadBody принимает файл как из multipart-формы (поле "file"), так и в теле запроса.
+func uploadBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
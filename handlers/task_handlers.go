@@ -2,16 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Yandex-Practicum/final-project/dates"
+	"github.com/Yandex-Practicum/final-project/middleware"
 	"github.com/Yandex-Practicum/final-project/models"
 	"github.com/Yandex-Practicum/final-project/service"
 )
 
-const LimitTasks = 50
+const (
+	LimitTasks    = 50
+	MaxLimitTasks = 500
+)
 
 func HandleAddTask(service *service.TaskService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -25,7 +34,7 @@ func HandleAddTask(service *service.TaskService) http.HandlerFunc {
 			return
 		}
 
-		id, err := service.AddTask(task)
+		id, err := service.AddTask(middleware.UserID(r.Context()), task)
 		if err != nil {
 			log.Printf("ошибка добавления задачи: %v", err)
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
@@ -43,8 +52,17 @@ func HandleEditTask(service *service.TaskService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
+		// fields - поля, которые клиент прислал: пропущенные сохраняют прежнее значение.
 		var task models.Task
-		err := json.NewDecoder(r.Body).Decode(&task)
+		var fields map[string]json.RawMessage
+		var body json.RawMessage
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err == nil {
+			err = json.Unmarshal(body, &task)
+		}
+		if err == nil {
+			err = json.Unmarshal(body, &fields)
+		}
 		if err != nil {
 			log.Printf("ошибка десериализации JSON: %v", err)
 			http.Error(w, `{"error": "Ошибка десериализации JSON"}`, http.StatusBadRequest)
@@ -62,9 +80,7 @@ func HandleEditTask(service *service.TaskService) http.HandlerFunc {
 			return
 		}
 
-		if task.Date == "" {
-			task.Date = time.Now().Format(dates.TimeFormat)
-		} else {
+		if task.Date != "" {
 			_, err := time.Parse(dates.TimeFormat, task.Date)
 			if err != nil {
 				log.Printf("дата представлена в неправильном формате")
@@ -73,22 +89,10 @@ func HandleEditTask(service *service.TaskService) http.HandlerFunc {
 			}
 		}
 
-		now := time.Now()
-		if task.Date < now.Format(dates.TimeFormat) {
-			if task.Repeat == "" {
-				task.Date = now.Format(dates.TimeFormat)
-			} else {
-				nextDate, err := dates.NextDate(now, task.Date, task.Repeat)
-				if err != nil {
-					log.Println(err.Error())
-					http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
-					return
-				}
-				task.Date = nextDate
-			}
+		err = service.KeepOmitted(middleware.UserID(r.Context()), &task, fields)
+		if err == nil {
+			err = service.EditTask(middleware.UserID(r.Context()), task)
 		}
-
-		err = service.EditTask(task)
 		if err != nil {
 			log.Println(err.Error())
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
@@ -114,7 +118,7 @@ func HandleGetTask(service *service.TaskService) http.HandlerFunc {
 		}
 
 		id := query.Get("id")
-		task, err := service.GetTask(id)
+		task, err := service.GetTask(middleware.UserID(r.Context()), id)
 		if err != nil {
 			log.Printf("ошибка получения задачи: %v", err)
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
@@ -128,19 +132,31 @@ func HandleGetTask(service *service.TaskService) http.HandlerFunc {
 	}
 }
 
-func HandleGetTasks(service *service.TaskService) http.HandlerFunc {
+func HandleGetTasks(taskService *service.TaskService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query, err := parseTaskQuery(r.URL.Query())
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+
 		search := r.URL.Query().Get("search")
 		var tasks []models.Task
-		var err error
+		var next string
 
 		if search != "" {
-			tasks, err = service.SearchTasks(search)
+			tasks, err = taskService.SearchTasks(middleware.UserID(r.Context()), search, query.Limit)
 		} else {
-			tasks, err = service.GetTasks(LimitTasks)
+			tasks, next, err = taskService.GetTasks(middleware.UserID(r.Context()), query)
 		}
 
+		if errors.Is(err, service.ErrInvalidQuery) {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			log.Printf("ошибка получения задач: %v", err)
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
@@ -151,13 +167,59 @@ func HandleGetTasks(service *service.TaskService) http.HandlerFunc {
 			tasks = []models.Task{}
 		}
 
-		err = json.NewEncoder(w).Encode(map[string]interface{}{"tasks": tasks})
+		response := map[string]interface{}{"tasks": tasks}
+		if next != "" {
+			response["next_cursor"] = next
+		}
+		err = json.NewEncoder(w).Encode(response)
 		if err != nil {
 			log.Printf("не удалось закодировать ответ: %v", err)
 		}
 	}
 }
 
+func parseTaskQuery(values url.Values) (models.TaskQuery, error) {
+	query := models.TaskQuery{
+		Sort:      values.Get("sort"),
+		Order:     values.Get("order"),
+		From:      values.Get("from"),
+		To:        values.Get("to"),
+		ProjectId: values.Get("project_id"),
+		Cursor:    values.Get("cursor"),
+		TagsMode:  values.Get("tags_mode"),
+		Limit:     LimitTasks,
+	}
+	for _, priorities := range values["priority"] {
+		for _, priority := range strings.Split(priorities, ",") {
+			if strings.TrimSpace(priority) != "" {
+				query.Priorities = append(query.Priorities, models.Priority(strings.TrimSpace(priority)))
+			}
+		}
+	}
+	for _, tags := range values["tags"] {
+		for _, tag := range strings.Split(tags, ",") {
+			if strings.TrimSpace(tag) != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+	if values.Has("limit") {
+		limit, err := strconv.Atoi(values.Get("limit"))
+		if err != nil || limit < 1 || limit > MaxLimitTasks {
+			return query, fmt.Errorf("число задач на странице должно быть от 1 до %d", MaxLimitTasks)
+		}
+		query.Limit = limit
+	}
+	if values.Has("has_repeat") {
+		hasRepeat, err := strconv.ParseBool(values.Get("has_repeat"))
+		if err != nil {
+			return query, errors.New("неправильное значение has_repeat")
+		}
+		query.HasRepeat = &hasRepeat
+	}
+	return query, nil
+}
+
 func HandleDeleteTask(service *service.TaskService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
@@ -170,7 +232,7 @@ func HandleDeleteTask(service *service.TaskService) http.HandlerFunc {
 		}
 
 		id := query.Get("id")
-		err := service.DeleteTask(id)
+		err := service.DeleteTask(middleware.UserID(r.Context()), id)
 		if err != nil {
 			log.Println(err.Error())
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
@@ -184,7 +246,7 @@ func HandleDeleteTask(service *service.TaskService) http.HandlerFunc {
 	}
 }
 
-func HandleTaskDone(service *service.TaskService) http.HandlerFunc {
+func HandleTaskDone(taskService *service.TaskService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		query := r.URL.Query()
@@ -196,36 +258,45 @@ func HandleTaskDone(service *service.TaskService) http.HandlerFunc {
 		}
 
 		id := query.Get("id")
-		task, err := service.GetTask(id)
+		err := taskService.DoneTask(middleware.UserID(r.Context()), id, query.Get("force") == "true")
+		if errors.Is(err, service.ErrTaskBlocked) {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusConflict)
+			return
+		}
 		if err != nil {
 			log.Println(err.Error())
 			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
 			return
 		}
 
-		if task.Repeat == "" {
-			err := service.DeleteTask(id)
-			if err != nil {
-				http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
-				return
-			}
-		} else {
-			now := time.Now()
-			nextDate, err := dates.NextDate(now, task.Date, task.Repeat)
-			if err != nil {
-				log.Println(err.Error())
-				http.Error(w, `{"error": "Ошибка вычисления следующей даты"}`, http.StatusInternalServerError)
-				return
-			}
-			task.Date = nextDate
-			err = service.EditTask(task)
-			if err != nil {
-				log.Println(err.Error())
-				http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleMoveTask(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		for _, param := range []string{"id", "project_id"} {
+			if !query.Has(param) {
+				log.Printf("пропущен обязательный параметр: %s", param)
+				http.Error(w, `{"error": "Пропущен обязательный параметр"}`, http.StatusBadRequest)
 				return
 			}
 		}
 
+		err := service.MoveTask(middleware.UserID(r.Context()), query.Get("id"), query.Get("project_id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
 		err = json.NewEncoder(w).Encode(map[string]interface{}{})
 		if err != nil {
 			log.Printf("не удалось закодировать ответ: %v", err)
@@ -262,3 +333,51 @@ func NextData(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write([]byte(nextDate))
 }
+
+func NextDates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	query := r.URL.Query()
+	params := []string{"now", "date", "repeat"}
+	for _, param := range params {
+		if !query.Has(param) {
+			log.Printf("пропущен обязательный параметр: %s", param)
+			http.Error(w, `{"error": "Пропущен обязательный параметр"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	currDate, err := time.Parse(dates.TimeFormat, query.Get("now"))
+	if err != nil {
+		log.Printf("неправильный формат даты: %v", err)
+		http.Error(w, `{"error": "Неправильный формат даты"}`, http.StatusBadRequest)
+		return
+	}
+
+	var count int
+	if query.Has("count") {
+		count, err = strconv.Atoi(query.Get("count"))
+		if err != nil {
+			log.Printf("неправильный формат количества дат: %v", err)
+			http.Error(w, `{"error": "Неправильный формат количества дат"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	occurrences, err := dates.Occurrences(
+		currDate,
+		query.Get("date"),
+		query.Get("repeat"),
+		count,
+		query.Get("to"),
+	)
+	if err != nil {
+		log.Println(err.Error())
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{"dates": occurrences})
+	if err != nil {
+		log.Printf("не удалось закодировать ответ: %v", err)
+	}
+}
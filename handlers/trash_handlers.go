@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Yandex-Practicum/final-project/middleware"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/service"
+)
+
+func HandleGetTrash(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		limit := LimitTasks
+		if r.URL.Query().Has("limit") {
+			var err error
+			limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+			if err != nil || limit < 1 || limit > MaxLimitTasks {
+				log.Printf("неправильное число задач: %s", r.URL.Query().Get("limit"))
+				http.Error(w, `{"error": "Неправильное число задач"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		tasks, err := service.GetTrash(middleware.UserID(r.Context()), limit)
+		if err != nil {
+			log.Printf("ошибка получения корзины: %v", err)
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if tasks == nil {
+			tasks = []models.Task{}
+		}
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"tasks": tasks})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandleRestoreTask(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		query := r.URL.Query()
+
+		if !query.Has("id") {
+			log.Println("отсутствует идентификатор")
+			http.Error(w, `{"error": "Отсутствует идентификатор"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := service.RestoreTask(middleware.UserID(r.Context()), query.Get("id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
+
+func HandlePurgeTrash(service *service.TaskService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+		purged, err := service.PurgeTrash(middleware.UserID(r.Context()), r.URL.Query().Get("id"))
+		if err != nil {
+			log.Println(err.Error())
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"purged": purged})
+		if err != nil {
+			log.Printf("не удалось закодировать ответ: %v", err)
+		}
+	}
+}
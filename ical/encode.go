@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineLength - предел длины строки в октетах по RFC 5545, более длинные строки переносятся.
+const maxLineLength = 75
+
+func (c *Component) Add(name string, value string) {
+	c.Properties = append(c.Properties, Property{Name: name, Value: value})
+}
+
+func (c *Component) AddWithParams(name string, params map[string]string, value string) {
+	c.Properties = append(c.Properties, Property{Name: name, Params: params, Value: value})
+}
+
+// Encode записывает компонент вместе с вложенными в формате iCalendar.
+func (c *Component) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	c.encode(bw)
+	return bw.Flush()
+}
+
+func (c *Component) encode(w *bufio.Writer) {
+	writeLine(w, "BEGIN:"+c.Name)
+	for _, prop := range c.Properties {
+		writeLine(w, prop.String())
+	}
+	for _, child := range c.Components {
+		child.encode(w)
+	}
+	writeLine(w, "END:"+c.Name)
+}
+
+func (p Property) String() string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	keys := make([]string, 0, len(p.Params))
+	for key := range p.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := p.Params[key]
+		if strings.ContainsAny(value, ";:,") {
+			value = `"` + value + `"`
+		}
+		b.WriteString(";" + key + "=" + value)
+	}
+	b.WriteString(":" + p.Value)
+	return b.String()
+}
+
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = maxLineLength - 1
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func Escape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,176 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	DateFormat     = "20060102"
+	DateTimeFormat = "20060102T150405"
+)
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+func (p Property) Time() (time.Time, error) {
+	if strings.HasSuffix(p.Value, "Z") {
+		return time.Parse(DateTimeFormat+"Z", p.Value)
+	}
+	loc := time.UTC
+	if tzid, ok := p.Params["TZID"]; ok {
+		tzLoc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("неизвестный часовой пояс: %s", tzid)
+		}
+		loc = tzLoc
+	}
+	for _, layout := range []string{DateTimeFormat, DateFormat} {
+		t, err := time.ParseInLocation(layout, p.Value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неверный формат даты %s: %s", p.Name, p.Value)
+}
+
+func (p Property) IsDate() bool {
+	return p.Params["VALUE"] == "DATE" || len(p.Value) == len(DateFormat)
+}
+
+func (c *Component) Get(name string) (Property, bool) {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop, true
+		}
+	}
+	return Property{}, false
+}
+
+func (c *Component) Value(name string) string {
+	prop, _ := c.Get(name)
+	return prop.Value
+}
+
+// Find возвращает все вложенные компоненты с именем name на любой глубине.
+func (c *Component) Find(name string) []*Component {
+	var found []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			found = append(found, child)
+		}
+		found = append(found, child.Find(name)...)
+	}
+	return found
+}
+
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &Component{}
+	stack := []*Component{root}
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		current := stack[len(stack)-1]
+		switch prop.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			current.Components = append(current.Components, component)
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 1 || current.Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("неожиданный конец компонента %s", prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("компонент %s не закрыт", stack[len(stack)-1].Name)
+	}
+	if len(root.Components) == 0 {
+		return nil, fmt.Errorf("календарь не содержит компонентов")
+	}
+	return root, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Property, error) {
+	var nameEnd, valueStart int
+	inQuotes := false
+	for i, ch := range line {
+		if ch == '"' {
+			inQuotes = !inQuotes
+		}
+		if inQuotes {
+			continue
+		}
+		if ch == ';' && nameEnd == 0 {
+			nameEnd = i
+		}
+		if ch == ':' {
+			valueStart = i
+			break
+		}
+	}
+	if valueStart == 0 {
+		return Property{}, fmt.Errorf("неверная строка календаря: %s", line)
+	}
+	if nameEnd == 0 {
+		nameEnd = valueStart
+	}
+
+	prop := Property{
+		Name:   strings.ToUpper(line[:nameEnd]),
+		Params: map[string]string{},
+		Value:  line[valueStart+1:],
+	}
+	if nameEnd < valueStart {
+		for _, param := range strings.Split(line[nameEnd+1:valueStart], ";") {
+			key, value, _ := strings.Cut(param, "=")
+			prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, nil
+}
+
+func Unescape(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(value)
+}
@@ -1,36 +1,110 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"os"
+	"time"
 
 	"github.com/Yandex-Practicum/final-project/models"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var secretKey = os.Getenv("SECRET_KEY")
+const (
+	userIDClaim    = "uid"
+	sessionIDClaim = "sid"
 
-func JWTCreate() (models.LoginResponse, error) {
-	jwtToken := jwt.New(jwt.SigningMethodHS256)
-	newToken, err := jwtToken.SignedString([]byte(secretKey))
+	defaultAccessTokenTTL = 8 * time.Hour
+)
+
+var (
+	ErrTokenExpired = errors.New("срок действия токена истёк")
+	ErrNoSecretKey  = errors.New("не задан SECRET_KEY")
+)
+
+type Claims struct {
+	UserID    int64
+	SessionID string
+}
+
+func JWTCreate(userID int64, sessionID string) (models.LoginResponse, error) {
+	tokenID, err := RandomID()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	now := time.Now()
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		userIDClaim:    userID,
+		sessionIDClaim: sessionID,
+		"jti":          tokenID,
+		"iat":          now.Unix(),
+		"exp":          now.Add(AccessTokenTTL()).Unix(),
+	})
+	key, err := SecretKey()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	newToken, err := jwtToken.SignedString(key)
 	if err != nil {
 		return models.LoginResponse{}, err
 	}
 	return models.LoginResponse{Token: newToken}, nil
 }
 
-func JWTValidate(tokenString string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// JWTValidate проверяет подпись и срок действия токена; токены без exp не принимаются.
+func JWTValidate(tokenString string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("неверный метод подписи")
 		}
-		return []byte(secretKey), nil
-	})
+		return SecretKey()
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return Claims{}, ErrTokenExpired
+	}
 	if err != nil {
-		return err
+		return Claims{}, err
 	}
 	if !token.Valid {
-		return errors.New("токен недействителен")
+		return Claims{}, errors.New("токен недействителен")
+	}
+
+	userID, ok := claims[userIDClaim].(float64)
+	if !ok {
+		return Claims{}, errors.New("неверный идентификатор пользователя в токене")
+	}
+	sessionID, ok := claims[sessionIDClaim].(string)
+	if !ok || sessionID == "" {
+		return Claims{}, errors.New("в токене нет идентификатора сессии")
+	}
+	return Claims{UserID: int64(userID), SessionID: sessionID}, nil
+}
+
+// SecretKey читает ключ подписи при каждом вызове: .env загружается уже после
+// инициализации пакетов. Пустой ключ не принимается, иначе токен подделает любой.
+func SecretKey() ([]byte, error) {
+	key := os.Getenv("SECRET_KEY")
+	if key == "" {
+		return nil, ErrNoSecretKey
+	}
+	return []byte(key), nil
+}
+
+func AccessTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("TODO_TOKEN_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultAccessTokenTTL
+	}
+	return ttl
+}
+
+func RandomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
 	}
-	return nil
+	return hex.EncodeToString(buf), nil
 }
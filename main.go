@@ -1,11 +1,16 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"time"
+	_ "time/tzdata"
 
+	"github.com/Yandex-Practicum/final-project/dates"
 	"github.com/Yandex-Practicum/final-project/handlers"
+	"github.com/Yandex-Practicum/final-project/jwt"
 	"github.com/Yandex-Practicum/final-project/middleware"
 	"github.com/Yandex-Practicum/final-project/service"
 	"github.com/Yandex-Practicum/final-project/storage"
@@ -13,35 +18,133 @@ import (
 	"github.com/joho/godotenv"
 )
 
+var migrateCommand = flag.String("migrate", "", "status | dry-run | up: работа с миграциями БД без запуска сервера")
+
 func main() {
+	flag.Parse()
 	err := godotenv.Load()
 	if err != nil {
 		log.Panicf("Some error occured. Err: %s", err)
 	}
+	if *migrateCommand != "" {
+		err = runMigrateCommand(*migrateCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if _, err := jwt.SecretKey(); err != nil {
+		log.Fatal(err)
+	}
 	db, err := storage.CreateDB()
 	if err != nil {
 		panic(err)
 	}
 	defer db.Close()
-	storage := storage.NewTaskStorage(db)
-	service := service.NewTaskService(storage)
+	holidayService := service.NewHolidayService(storage.NewHolidayStorage(db))
+	err = holidayService.Load()
+	if err != nil {
+		panic(err)
+	}
+	dates.SetCalendar(holidayService)
+	userService := service.NewUserService(storage.NewUserStorage(db))
+	sessionService := service.NewSessionService(storage.NewSessionStorage(db))
+	apiTokenService := service.NewAPITokenService(storage.NewAPITokenStorage(db))
+	auth := middleware.Auth(sessionService, apiTokenService)
+	projectStorage := storage.NewProjectStorage(db)
+	projectService := service.NewProjectService(projectStorage)
+	webhookService := service.NewWebhookService(storage.NewWebhookStorage(db))
+	taskService := service.NewTaskService(storage.NewTaskStorage(db), storage.NewCompletionStorage(db), projectStorage, webhookService)
+	checklistService := service.NewChecklistService(storage.NewChecklistStorage(db), taskService)
+	dependencyService := service.NewDependencyService(storage.NewDependencyStorage(db), taskService)
+	backupService := service.NewBackupService(taskService, checklistService, dependencyService)
+	caldavService := service.NewCalDAVService(taskService, storage.NewCalDAVStorage(db))
+	go purgeTrash(taskService)
+	go webhookService.Run()
 	web_server_port := os.Getenv("TODO_PORT")
+	chi.RegisterMethod("PROPFIND")
+	chi.RegisterMethod("REPORT")
 	mux := chi.NewRouter()
 	mux.Handle("/*", http.FileServer(http.Dir("./web")))
-	mux.Post("/api/signin", handlers.HangdleLogin)
+	mux.Post("/api/signin", handlers.HangdleLogin(userService, sessionService))
+	mux.Post("/api/signup", handlers.HandleRegister(userService, sessionService))
+	mux.Post("/api/refresh", handlers.HandleRefresh(sessionService))
+	mux.Post("/api/signout", auth(handlers.HandleSignout(sessionService)))
+	mux.Post("/api/tokens", auth(handlers.HandleCreateAPIToken(apiTokenService)))
+	mux.Get("/api/tokens", auth(handlers.HandleGetAPITokens(apiTokenService)))
+	mux.Delete("/api/tokens", auth(handlers.HandleRevokeAPIToken(apiTokenService)))
 	mux.Get("/api/nextdate", handlers.NextData)
+	mux.Get("/api/nextdates", handlers.NextDates)
+
+	mux.Post("/api/task", auth(handlers.HandleAddTask(taskService)))
+	mux.Get("/api/task", auth(handlers.HandleGetTask(taskService)))
+	mux.Put("/api/task", auth(handlers.HandleEditTask(taskService)))
+	mux.Delete("/api/task", auth(handlers.HandleDeleteTask(taskService)))
+
+	mux.Post("/api/task/done", auth(handlers.HandleTaskDone(taskService)))
+	mux.Post("/api/task/undone", auth(handlers.HandleTaskUndone(taskService)))
+	mux.Get("/api/completions", auth(handlers.HandleGetCompletions(taskService)))
+
+	mux.Post("/api/task/checklist", auth(handlers.HandleAddChecklistItem(checklistService)))
+	mux.Get("/api/task/checklist", auth(handlers.HandleGetChecklist(checklistService)))
+	mux.Put("/api/task/checklist", auth(handlers.HandleEditChecklistItem(checklistService)))
+	mux.Delete("/api/task/checklist", auth(handlers.HandleDeleteChecklistItem(checklistService)))
+	mux.Post("/api/task/checklist/check", auth(handlers.HandleCheckChecklistItem(checklistService, true)))
+	mux.Post("/api/task/checklist/uncheck", auth(handlers.HandleCheckChecklistItem(checklistService, false)))
+	mux.Post("/api/task/checklist/reorder", auth(handlers.HandleReorderChecklist(checklistService)))
 
-	mux.Post("/api/task", middleware.Auth(handlers.HandleAddTask(service)))
-	mux.Get("/api/task", middleware.Auth(handlers.HandleGetTask(service)))
-	mux.Put("/api/task", middleware.Auth(handlers.HandleEditTask(service)))
-	mux.Delete("/api/task", middleware.Auth(handlers.HandleDeleteTask(service)))
+	mux.Post("/api/task/dependency", auth(handlers.HandleAddDependency(dependencyService)))
+	mux.Delete("/api/task/dependency", auth(handlers.HandleDeleteDependency(dependencyService)))
 
-	mux.Post("/api/task/done", middleware.Auth(handlers.HandleTaskDone(service)))
+	mux.Post("/api/task/move", auth(handlers.HandleMoveTask(taskService)))
 
-	mux.Get("/api/tasks", middleware.Auth(handlers.HandleGetTasks(service)))
+	mux.Get("/api/tasks", auth(handlers.HandleGetTasks(taskService)))
+	mux.Post("/api/tasks/import", auth(handlers.HandleImportCalendar(taskService)))
+	mux.Get("/api/tasks/export", auth(handlers.HandleExportTasks(backupService)))
+	mux.Post("/api/tasks/bulk", auth(handlers.HandleImportTasks(backupService)))
+	mux.Get("/api/tasks.ics", middleware.QueryToken(auth(handlers.HandleCalendarFeed(taskService))))
+
+	mux.Post("/api/project", auth(handlers.HandleAddProject(projectService)))
+	mux.Get("/api/project", auth(handlers.HandleGetProject(projectService)))
+	mux.Put("/api/project", auth(handlers.HandleEditProject(projectService)))
+	mux.Delete("/api/project", auth(handlers.HandleDeleteProject(projectService)))
+	mux.Get("/api/projects", auth(handlers.HandleGetProjects(projectService)))
+
+	mux.Get("/api/trash", auth(handlers.HandleGetTrash(taskService)))
+	mux.Post("/api/trash/restore", auth(handlers.HandleRestoreTask(taskService)))
+	mux.Delete("/api/trash", auth(handlers.HandlePurgeTrash(taskService)))
+
+	mux.Post("/api/webhook", auth(handlers.HandleAddWebhook(webhookService)))
+	mux.Delete("/api/webhook", auth(handlers.HandleDeleteWebhook(webhookService)))
+	mux.Get("/api/webhooks", auth(handlers.HandleGetWebhooks(webhookService)))
+	mux.Get("/api/webhooks/deliveries", auth(handlers.HandleGetDeliveries(webhookService)))
+	mux.Post("/api/webhooks/deliveries/retry", auth(handlers.HandleRetryDelivery(webhookService)))
+
+	mux.Post("/api/holiday", auth(middleware.OwnerOnly(handlers.HandleAddHoliday(holidayService))))
+	mux.Put("/api/holiday", auth(middleware.OwnerOnly(handlers.HandleEditHoliday(holidayService))))
+	mux.Delete("/api/holiday", auth(middleware.OwnerOnly(handlers.HandleDeleteHoliday(holidayService))))
+	mux.Get("/api/holidays", auth(handlers.HandleGetHolidays(holidayService)))
+	mux.Post("/api/holidays/import", auth(middleware.OwnerOnly(handlers.HandleImportHolidays(holidayService))))
+
+	caldav := middleware.BasicAuth(auth(handlers.HandleCalDAV(caldavService)))
+	mux.Handle("/.well-known/caldav", http.RedirectHandler(handlers.CalDAVRoot, http.StatusMovedPermanently))
+	mux.HandleFunc(handlers.CalDAVRoot+"*", caldav)
 
 	err = http.ListenAndServe(":"+web_server_port, mux)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// purgeTrash раз в час удаляет задачи, срок хранения которых в корзине истёк.
+func purgeTrash(service *service.TaskService) {
+	for {
+		purged, err := service.PurgeExpiredTrash()
+		if err != nil {
+			log.Printf("ошибка очистки корзины: %v", err)
+		} else if purged > 0 {
+			log.Printf("из корзины удалено задач: %d", purged)
+		}
+		time.Sleep(time.Hour)
+	}
+}
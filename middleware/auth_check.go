@@ -1,29 +1,146 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
-	"os"
+	"strings"
 
 	"github.com/Yandex-Practicum/final-project/jwt"
+	"github.com/Yandex-Practicum/final-project/models"
 )
 
-var pass = os.Getenv("TODO_PASSWORD")
+type contextKey string
 
-func Auth(next http.HandlerFunc) http.HandlerFunc {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if len(pass) > 0 {
-			var cookieToken string
-			cookie, err := r.Cookie("token")
-			if err == nil {
-				cookieToken = cookie.Value
+const identityKey contextKey = "identity"
+
+type SessionChecker interface {
+	IsRevoked(sessionID string, userID int64) bool
+}
+
+type TokenChecker interface {
+	Authenticate(token string) (int64, bool, error)
+}
+
+type identity struct {
+	userID    int64
+	sessionID string
+	readOnly  bool
+}
+
+// Auth пропускает только запросы с действительным токеном: JWT сессии или API-токеном.
+// Общий список задач доступен по токену, выданному за TODO_PASSWORD.
+func Auth(sessions SessionChecker, tokens TokenChecker) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if strings.HasPrefix(token, models.APITokenPrefix) {
+				userID, readOnly, err := tokens.Authenticate(token)
+				if err != nil {
+					http.Error(w, `{"error": "Недействительный API-токен", "code": "token_invalid"}`, http.StatusUnauthorized)
+					return
+				}
+				if readOnly && !safeMethod(r.Method) {
+					http.Error(w, `{"error": "Токен доступен только для чтения", "code": "token_read_only"}`, http.StatusForbidden)
+					return
+				}
+				next(w, r.WithContext(context.WithValue(r.Context(), identityKey, identity{
+					userID:   userID,
+					readOnly: readOnly,
+				})))
+				return
 			}
-			valid := jwt.JWTValidate(cookieToken)
 
-			if valid != nil {
-				http.Error(w, "Authentification required", http.StatusUnauthorized)
+			if token == "" {
+				cookie, err := r.Cookie("token")
+				if err == nil {
+					token = cookie.Value
+				}
+			}
+			claims, valid := jwt.JWTValidate(token)
+
+			switch {
+			case errors.Is(valid, jwt.ErrTokenExpired):
+				http.Error(w, `{"error": "Срок действия токена истёк", "code": "token_expired"}`, http.StatusUnauthorized)
+				return
+			case valid == nil && sessions.IsRevoked(claims.SessionID, claims.UserID):
+				http.Error(w, `{"error": "Токен отозван", "code": "token_revoked"}`, http.StatusUnauthorized)
+				return
+			case valid != nil:
+				http.Error(w, `{"error": "Требуется авторизация", "code": "auth_required"}`, http.StatusUnauthorized)
 				return
 			}
+			next(w, r.WithContext(context.WithValue(r.Context(), identityKey, identity{
+				userID:    claims.UserID,
+				sessionID: claims.SessionID,
+			})))
+		})
+	}
+}
+
+// OwnerOnly пропускает только владельца сервера - пользователя, вошедшего по TODO_PASSWORD.
+// Так защищены общие для всех пользователей данные, например календарь выходных.
+func OwnerOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if UserID(r.Context()) != 0 {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			http.Error(w, `{"error": "Действие доступно только владельцу сервера", "code": "owner_only"}`, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// QueryToken принимает токен из параметра token в адресе: календарные
+// приложения подписываются на ленту по ссылке и не умеют передавать заголовки.
+func QueryToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && bearerToken(r) == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next(w, r)
+	}
+}
+
+// BasicAuth принимает токен (API-токен или JWT) паролем в Basic-авторизации,
+// имя пользователя не проверяется. Так авторизуются клиенты CalDAV.
+func BasicAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); ok {
+			r.Header.Set("Authorization", "Bearer "+password)
+		} else if r.Header.Get("Authorization") == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="todo"`)
+			http.Error(w, "Authentification required", http.StatusUnauthorized)
+			return
 		}
 		next(w, r)
-	})
+	}
+}
+
+func UserID(ctx context.Context) int64 {
+	id, _ := ctx.Value(identityKey).(identity)
+	return id.userID
+}
+
+// SessionID пуст для анонимных запросов и запросов с API-токеном.
+func SessionID(ctx context.Context) string {
+	id, _ := ctx.Value(identityKey).(identity)
+	return id.sessionID
+}
+
+// safeMethod - запрос только читает данные, такие запросы доступны токенам на чтение.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND", "REPORT":
+		return true
+	}
+	return false
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+	return ""
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+// runMigrateCommand выполняет команду -migrate и не запускает сервер.
+func runMigrateCommand(command string) error {
+	db, err := storage.OpenDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch command {
+	case "status":
+		applied, err := storage.AppliedMigrations(db)
+		if err != nil {
+			return err
+		}
+		pending, err := storage.PendingMigrations(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			fmt.Printf("applied  %04d_%s  %s\n", migration.Version, migration.Name, migration.AppliedAt)
+		}
+		for _, migration := range pending {
+			fmt.Printf("pending  %04d_%s\n", migration.Version, migration.Name)
+		}
+		if len(pending) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "dry-run":
+		pending, err := storage.PendingMigrations(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			fmt.Printf("-- %04d_%s\n%s\n", migration.Version, migration.Name, migration.SQL)
+		}
+		if len(pending) == 0 {
+			fmt.Println("-- schema is up to date")
+		}
+	case "up":
+		applied, err := storage.Migrate(db)
+		for _, migration := range applied {
+			fmt.Printf("applied  %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected status, dry-run or up", command)
+	}
+	return nil
+}
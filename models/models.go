@@ -1,21 +1,179 @@
 package models
 
 import (
+	"encoding/json"
+
 	_ "modernc.org/sqlite"
 )
 
 type Task struct {
-	Id      string `json:"id"`
-	Date    string `json:"date"`
-	Title   string `json:"title"`
-	Comment string `json:"comment"`
-	Repeat  string `json:"repeat"`
+	Id          string          `json:"id"`
+	Date        string          `json:"date"`
+	Title       string          `json:"title"`
+	Comment     string          `json:"comment"`
+	Repeat      string          `json:"repeat"`
+	RepeatUntil string          `json:"repeat_until,omitempty" db:"repeat_until"`
+	RepeatCount int             `json:"repeat_count,omitempty" db:"repeat_count"`
+	Time        string          `json:"time,omitempty"`
+	Timezone    string          `json:"timezone,omitempty"`
+	Priority    Priority        `json:"priority,omitempty"`
+	ProjectId   string          `json:"project_id,omitempty" db:"project_id"`
+	Tags        []string        `json:"tags,omitempty" db:"-"`
+	Checklist   []ChecklistItem `json:"checklist,omitempty" db:"-"`
+	BlockedBy   []TaskRef       `json:"blocked_by,omitempty" db:"-"`
+	Blocking    []TaskRef       `json:"blocking,omitempty" db:"-"`
+	Snippet     string          `json:"snippet,omitempty" db:"snippet"`
+	DeletedAt   string          `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// TaskRef - краткая ссылка на задачу в списках зависимостей.
+type TaskRef struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Dependency означает, что задача TaskId не может быть выполнена раньше DependsOn.
+type Dependency struct {
+	TaskId    string `json:"task_id" db:"task_id"`
+	DependsOn string `json:"depends_on" db:"depends_on_id"`
+}
+
+// TaskQuery описывает выборку списка задач: сортировку, фильтры и страницу.
+type TaskQuery struct {
+	Sort       string
+	Order      string
+	From       string
+	To         string
+	ProjectId  string
+	HasRepeat  *bool
+	Priorities []Priority
+	Tags       []string
+	TagsMode   string
+	Cursor     string
+	Limit      int
+}
+
+// Completion - запись о выполнении задачи. Хранит состояние задачи
+// на момент выполнения, чтобы выполнение можно было отменить.
+type Completion struct {
+	Id          string          `json:"id"`
+	TaskId      string          `json:"task_id" db:"task_id"`
+	Date        string          `json:"date"`
+	Title       string          `json:"title"`
+	Comment     string          `json:"comment"`
+	Repeat      string          `json:"repeat"`
+	RepeatUntil string          `json:"repeat_until,omitempty" db:"repeat_until"`
+	RepeatCount int             `json:"repeat_count,omitempty" db:"repeat_count"`
+	Time        string          `json:"time,omitempty"`
+	Timezone    string          `json:"timezone,omitempty"`
+	Priority    Priority        `json:"priority,omitempty"`
+	ProjectId   string          `json:"project_id,omitempty" db:"project_id"`
+	Tags        []string        `json:"tags,omitempty" db:"-"`
+	Checklist   []ChecklistItem `json:"checklist,omitempty" db:"-"`
+	CompletedOn string          `json:"completed_on" db:"completed_on"`
+	CompletedAt string          `json:"completed_at" db:"completed_at"`
+}
+
+type Project struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Color    string `json:"color,omitempty"`
+	Archived bool   `json:"archived"`
+	Default  bool   `json:"default" db:"is_default"`
+}
+
+// CalDAVResource связывает задачу с именем ресурса и UID, которые выбрал клиент CalDAV.
+type CalDAVResource struct {
+	Name   string `db:"name"`
+	UID    string `db:"uid"`
+	TaskId string `db:"task_id"`
+}
+
+type ChecklistItem struct {
+	Id       string `json:"id"`
+	TaskId   string `json:"task_id" db:"task_id"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+	Done     bool   `json:"done"`
+}
+
+type ChecklistOrder struct {
+	TaskId string   `json:"task_id"`
+	Ids    []string `json:"ids"`
+}
+
+type CompletionQuery struct {
+	TaskId string
+	From   string
+	To     string
+	Limit  int
+}
+
+type Holiday struct {
+	Id    string `json:"id"`
+	Date  string `json:"date"`
+	Title string `json:"title"`
+}
+
+type User struct {
+	Id           int64  `db:"id"`
+	Login        string `db:"login"`
+	PasswordHash string `db:"password_hash"`
 }
 
 type Login struct {
+	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+const APITokenPrefix = "pat_"
+
+type APIToken struct {
+	Id         int64  `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`
+	Scope      string `json:"scope" db:"scope"`
+	CreatedAt  string `json:"created_at" db:"created_at"`
+	LastUsedAt string `json:"last_used_at" db:"last_used_at"`
+	Token      string `json:"token,omitempty" db:"-"`
+}
+
+// Webhook - подписка на события задач. Пустой список Events означает все события.
+type Webhook struct {
+	Id        int64    `json:"id" db:"id"`
+	Url       string   `json:"url" db:"url"`
+	Secret    string   `json:"secret,omitempty" db:"secret"`
+	Events    []string `json:"events" db:"-"`
+	CreatedAt string   `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery - запись журнала доставки события подписчику.
+type WebhookDelivery struct {
+	Id            int64           `json:"id" db:"id"`
+	WebhookId     int64           `json:"webhook_id" db:"webhook_id"`
+	Event         string          `json:"event" db:"event"`
+	Payload       json.RawMessage `json:"payload" db:"-"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	ResponseCode  int             `json:"response_code,omitempty" db:"response_code"`
+	Error         string          `json:"error,omitempty" db:"error"`
+	NextAttemptAt int64           `json:"-" db:"next_attempt_at"`
+	CreatedAt     string          `json:"created_at" db:"created_at"`
+	DeliveredAt   string          `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type Session struct {
+	Id          string `db:"id"`
+	UserId      int64  `db:"user_id"`
+	RefreshHash string `db:"refresh_hash"`
+	ExpiresAt   int64  `db:"expires_at"`
+	Revoked     bool   `db:"revoked"`
 }
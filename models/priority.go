@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Priority - приоритет задачи. В БД хранится числом, чтобы по нему
+// можно было сортировать, в API передаётся названием.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+var priorityRanks = map[Priority]int64{
+	PriorityLow:    1,
+	PriorityNormal: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+func (p Priority) Valid() bool {
+	_, ok := priorityRanks[p]
+	return ok
+}
+
+// Rank возвращает числовое значение приоритета, 0 для пустого.
+func (p Priority) Rank() int64 {
+	return priorityRanks[p]
+}
+
+func (p Priority) Value() (driver.Value, error) {
+	if p == "" {
+		return int64(0), nil
+	}
+	rank, ok := priorityRanks[p]
+	if !ok {
+		return nil, fmt.Errorf("unknown priority: %s", string(p))
+	}
+	return rank, nil
+}
+
+func (p *Priority) Scan(src any) error {
+	rank, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("priority scan error: %T", src)
+	}
+	for priority, value := range priorityRanks {
+		if value == rank {
+			*p = priority
+			return nil
+		}
+	}
+	*p = ""
+	return nil
+}
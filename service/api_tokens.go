@@ -0,0 +1,76 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+
+	maxTokenNameLength = 64
+)
+
+type APITokenService struct {
+	storage *storage.APITokenStorage
+}
+
+func NewAPITokenService(storage *storage.APITokenStorage) *APITokenService {
+	return &APITokenService{storage: storage}
+}
+
+// Create возвращает токен вместе с секретом; в БД хранится только его хеш.
+func (s *APITokenService) Create(userID int64, token models.APIToken) (models.APIToken, error) {
+	if token.Name == "" || utf8.RuneCountInString(token.Name) > maxTokenNameLength {
+		return models.APIToken{}, errors.New("имя токена должно содержать от 1 до 64 символов")
+	}
+	if token.Scope == "" {
+		token.Scope = ScopeWrite
+	}
+	if token.Scope != ScopeRead && token.Scope != ScopeWrite {
+		return models.APIToken{}, errors.New("область доступа токена должна быть read или write")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return models.APIToken{}, err
+	}
+	secret := models.APITokenPrefix + hex.EncodeToString(buf)
+
+	token.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	token.LastUsedAt = ""
+	id, err := s.storage.AddToken(userID, token, hashSecret(secret))
+	if err != nil {
+		return models.APIToken{}, err
+	}
+	token.Id = id
+	token.Token = secret
+	return token, nil
+}
+
+func (s *APITokenService) List(userID int64) ([]models.APIToken, error) {
+	return s.storage.GetTokens(userID)
+}
+
+func (s *APITokenService) Revoke(userID int64, id string) error {
+	return s.storage.RevokeToken(userID, id)
+}
+
+func (s *APITokenService) Authenticate(token string) (int64, bool, error) {
+	id, userID, scope, err := s.storage.GetTokenOwner(hashSecret(token))
+	if err != nil {
+		return 0, false, err
+	}
+	err = s.storage.TouchToken(id, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, false, err
+	}
+	return userID, scope == ScopeRead, nil
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const (
+	ImportAppend = "append"
+	ImportMerge  = "merge"
+)
+
+// csvColumns - столбцы CSV-выгрузки задач. Теги и идентификаторы задач в depends_on
+// записываются через TagSeparator, пункты чек-листа - по строке на пункт.
+var csvColumns = []string{
+	"id", "date", "title", "comment", "repeat", "repeat_until", "repeat_count",
+	"time", "timezone", "priority", "project_id", "tags", "checklist", "depends_on",
+}
+
+// Отметки пунктов чек-листа в CSV.
+const (
+	csvItemDone = "[x] "
+	csvItemOpen = "[ ] "
+)
+
+// BackupTask - задача в резервной копии вместе с идентификаторами задач, от которых она зависит.
+type BackupTask struct {
+	models.Task
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// BackupService выгружает и загружает задачи вместе с чек-листами и зависимостями.
+type BackupService struct {
+	tasks        *TaskService
+	checklists   *ChecklistService
+	dependencies *DependencyService
+}
+
+func NewBackupService(tasks *TaskService, checklists *ChecklistService, dependencies *DependencyService) *BackupService {
+	return &BackupService{tasks: tasks, checklists: checklists, dependencies: dependencies}
+}
+
+// TaskImportResult - итог загрузки задач из JSON или CSV. Номера строк в ошибках
+// считаются с 1 по порядку задач в файле, без строки заголовка CSV.
+type TaskImportResult struct {
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Id    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// ExportTasks возвращает все задачи пользователя вне корзины с чек-листами и зависимостями.
+// Выгружаются только невыполненные зависимости: выполненная больше не блокирует задачу.
+func (s *BackupService) ExportTasks(userID int64) ([]BackupTask, error) {
+	tasks, err := s.tasks.storage.AllTasks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := make([]BackupTask, 0, len(tasks))
+	for _, task := range tasks {
+		task, err = s.tasks.GetTask(userID, task.Id)
+		if err != nil {
+			return nil, err
+		}
+		var dependsOn []string
+		for _, ref := range task.BlockedBy {
+			dependsOn = append(dependsOn, ref.Id)
+		}
+		task.BlockedBy, task.Blocking = nil, nil
+		backup = append(backup, BackupTask{Task: task, DependsOn: dependsOn})
+	}
+	return backup, nil
+}
+
+// ImportTasks загружает задачи из JSON или CSV в формате выгрузки. В режиме append
+// все задачи создаются заново, в режиме merge задача с существующим id обновляется,
+// а новая задача получает id из файла, если он свободен. Каждая задача проверяется
+// так же, как при добавлении через API. Зависимости добавляются после всех задач,
+// ссылки на задачи из файла переводятся в их новые id.
+func (s *BackupService) ImportTasks(userID int64, r io.Reader, format string, mode string) (TaskImportResult, error) {
+	if mode == "" {
+		mode = ImportAppend
+	}
+	if mode != ImportAppend && mode != ImportMerge {
+		return TaskImportResult{}, errors.New("режим импорта должен быть append или merge")
+	}
+
+	var tasks []BackupTask
+	var rowErrors map[int]error
+	var err error
+	switch format {
+	case "", "json":
+		tasks, err = readTasksJSON(r)
+	case "csv":
+		tasks, rowErrors, err = readTasksCSV(r)
+	default:
+		return TaskImportResult{}, errors.New("формат должен быть json или csv")
+	}
+	if err != nil {
+		return TaskImportResult{}, err
+	}
+
+	result := TaskImportResult{Errors: []ImportRowError{}}
+	rowError := func(row int, id string, err error) {
+		result.Errors = append(result.Errors, ImportRowError{Row: row + 1, Id: id, Error: err.Error()})
+	}
+	// ids переводит id задачи из файла в id загруженной задачи.
+	ids := make(map[string]string)
+	imported := make([]string, len(tasks))
+	for i, task := range tasks {
+		updated := false
+		err := rowErrors[i]
+		if err == nil {
+			imported[i], updated, err = s.importRow(userID, task.Task, mode)
+		}
+		switch {
+		case err != nil:
+			result.Failed++
+			rowError(i, task.Id, err)
+			continue
+		case updated:
+			result.Updated++
+		default:
+			result.Created++
+		}
+		if task.Id != "" {
+			ids[task.Id] = imported[i]
+		}
+		if err := s.importChecklist(userID, imported[i], task.Checklist, updated); err != nil {
+			rowError(i, task.Id, err)
+		}
+	}
+
+	for i, task := range tasks {
+		if imported[i] == "" {
+			continue
+		}
+		for _, dependsOn := range task.DependsOn {
+			target, ok := ids[dependsOn]
+			if !ok && mode == ImportMerge {
+				target = dependsOn
+			}
+			err := fmt.Errorf("задача %s не найдена", dependsOn)
+			if target != "" {
+				err = s.dependencies.AddDependency(userID, models.Dependency{TaskId: imported[i], DependsOn: target})
+			}
+			if err != nil {
+				rowError(i, task.Id, fmt.Errorf("зависимость от %s: %w", dependsOn, err))
+			}
+		}
+	}
+	return result, nil
+}
+
+// importRow сохраняет задачу и возвращает её id. В режиме merge задача с существующим id
+// обновляется, а новая сохраняет id из файла, если он не занят.
+func (s *BackupService) importRow(userID int64, task models.Task, mode string) (id string, updated bool, err error) {
+	// Проекты не выгружаются: задача из чужого или удалённого проекта попадает
+	// во «Входящие», а у обновляемой задачи проект не меняется.
+	if task.ProjectId != "" {
+		_, err := s.tasks.projects.GetProject(userID, task.ProjectId)
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			task.ProjectId = ""
+		} else if err != nil {
+			return "", false, err
+		}
+	}
+	if mode == ImportMerge && task.Id != "" {
+		if _, err := s.tasks.GetTask(userID, task.Id); err == nil {
+			return task.Id, true, s.tasks.EditTask(userID, task)
+		}
+		free, err := s.tasks.storage.IsTaskIdFree(task.Id)
+		if err != nil {
+			return "", false, err
+		}
+		if !free {
+			task.Id = ""
+		}
+	} else {
+		task.Id = ""
+	}
+
+	newID, err := s.tasks.addTask(userID, task)
+	if err != nil {
+		return "", false, err
+	}
+	return strconv.FormatInt(newID, 10), false, nil
+}
+
+// importChecklist заменяет чек-лист задачи пунктами из файла. Если чек-листа
+// в файле нет, у обновлённой задачи он остаётся прежним.
+func (s *BackupService) importChecklist(userID int64, taskID string, items []models.ChecklistItem, updated bool) error {
+	if items == nil {
+		return nil
+	}
+	if updated {
+		current, err := s.checklists.GetItems(userID, taskID)
+		if err != nil {
+			return err
+		}
+		for _, item := range current {
+			if err := s.checklists.DeleteItem(userID, item.Id); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, item := range items {
+		item.TaskId = taskID
+		id, err := s.checklists.AddItem(userID, item)
+		if err != nil {
+			return fmt.Errorf("пункт чек-листа %s: %w", item.Title, err)
+		}
+		if item.Done {
+			err = s.checklists.CheckItem(userID, strconv.FormatInt(id, 10), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func WriteTasksCSV(w io.Writer, tasks []BackupTask) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvColumns)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		err = writer.Write([]string{
+			task.Id, task.Date, task.Title, task.Comment, task.Repeat, task.RepeatUntil,
+			strconv.Itoa(task.RepeatCount), task.Time, task.Timezone, string(task.Priority),
+			task.ProjectId, strings.Join(task.Tags, storage.TagSeparator),
+			checklistCSV(task.Checklist), strings.Join(task.DependsOn, storage.TagSeparator),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func checklistCSV(items []models.ChecklistItem) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		mark := csvItemOpen
+		if item.Done {
+			mark = csvItemDone
+		}
+		lines = append(lines, mark+item.Title)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseChecklistCSV читает пункты чек-листа по строке на пункт, строка без
+// отметки [x] или [ ] считается невыполненным пунктом.
+func parseChecklistCSV(value string) []models.ChecklistItem {
+	items := []models.ChecklistItem{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		item := models.ChecklistItem{Title: line}
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(strings.ToLower(line), csvItemDone):
+			item.Title, item.Done = line[len(csvItemDone):], true
+		case strings.HasPrefix(line, csvItemOpen):
+			item.Title = line[len(csvItemOpen):]
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func readTasksJSON(r io.Reader) ([]BackupTask, error) {
+	var backup struct {
+		Tasks []BackupTask `json:"tasks"`
+	}
+	err := json.NewDecoder(r).Decode(&backup)
+	if err != nil {
+		return nil, errors.New("ошибка десериализации JSON")
+	}
+	return backup.Tasks, nil
+}
+
+// readTasksCSV читает задачи по заголовку CSV, порядок столбцов может быть любым.
+// Ошибки разбора отдельных строк возвращаются по номеру задачи.
+func readTasksCSV(r io.Reader) ([]BackupTask, map[int]error, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("ошибка чтения заголовка CSV")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, errors.New("в CSV нет столбца title")
+	}
+
+	var tasks []BackupTask
+	rowErrors := make(map[int]error)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, err
+			}
+			rowErrors[len(tasks)] = errors.New("неверный формат строки CSV")
+			tasks = append(tasks, BackupTask{})
+			continue
+		}
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		task := models.Task{
+			Id:          value("id"),
+			Date:        value("date"),
+			Title:       value("title"),
+			Comment:     value("comment"),
+			Repeat:      value("repeat"),
+			RepeatUntil: value("repeat_until"),
+			Time:        value("time"),
+			Timezone:    value("timezone"),
+			Priority:    models.Priority(value("priority")),
+			ProjectId:   value("project_id"),
+			Tags:        []string{},
+		}
+		if tags := value("tags"); tags != "" {
+			task.Tags = strings.Split(tags, storage.TagSeparator)
+		}
+		if _, ok := columns["checklist"]; ok {
+			task.Checklist = parseChecklistCSV(value("checklist"))
+		}
+		var dependsOn []string
+		if ids := value("depends_on"); ids != "" {
+			dependsOn = strings.Split(ids, storage.TagSeparator)
+		}
+		if count := value("repeat_count"); count != "" {
+			task.RepeatCount, err = strconv.Atoi(count)
+			if err != nil {
+				rowErrors[len(tasks)] = errors.New("неверное число повторений")
+			}
+		}
+		tasks = append(tasks, BackupTask{Task: task, DependsOn: dependsOn})
+	}
+	return tasks, rowErrors, nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/dates"
+	"github.com/Yandex-Practicum/final-project/ical"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+var (
+	ErrItemNotFound       = errors.New("задача не найдена")
+	ErrPreconditionFailed = errors.New("задача изменена другим клиентом")
+	ErrItemClosed         = errors.New("нельзя создать уже выполненную или отменённую задачу")
+)
+
+// CalDAVItem - задача в виде ресурса коллекции CalDAV.
+type CalDAVItem struct {
+	Name string
+	ETag string
+	Data string
+}
+
+// CalDAVService представляет задачи пользователя коллекцией VTODO для клиентов CalDAV.
+type CalDAVService struct {
+	tasks   *TaskService
+	storage *storage.CalDAVStorage
+}
+
+func NewCalDAVService(tasks *TaskService, storage *storage.CalDAVStorage) *CalDAVService {
+	return &CalDAVService{tasks: tasks, storage: storage}
+}
+
+func (s *CalDAVService) GetItems(userID int64) ([]CalDAVItem, error) {
+	tasks, err := s.tasks.storage.AllTasks(userID)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := s.storage.GetResources(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]CalDAVItem, 0, len(tasks))
+	for _, task := range tasks {
+		items = append(items, caldavItem(task, resources[task.Id]))
+	}
+	return items, nil
+}
+
+func (s *CalDAVService) GetItem(userID int64, name string) (CalDAVItem, error) {
+	task, resource, err := s.find(userID, name)
+	if err != nil {
+		return CalDAVItem{}, err
+	}
+	return caldavItem(task, resource), nil
+}
+
+// CTag меняется при любом изменении коллекции, по нему клиенты решают, нужна ли синхронизация.
+func (s *CalDAVService) CTag(userID int64) (string, error) {
+	items, err := s.GetItems(userID)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, item := range items {
+		io.WriteString(hash, item.Name+":"+item.ETag+"\n")
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+// PutItem создаёт или изменяет задачу по VTODO из r. Выполненная VTODO отмечает
+// задачу выполненной. ifMatch и ifNoneMatch - условия из заголовков запроса.
+func (s *CalDAVService) PutItem(userID int64, name string, r io.Reader, ifMatch, ifNoneMatch string) (created bool, etag string, err error) {
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return false, "", err
+	}
+	var todo *ical.Component
+	for _, component := range calendar.Find("VTODO") {
+		if _, ok := component.Get("RECURRENCE-ID"); !ok {
+			todo = component
+			break
+		}
+	}
+	if todo == nil {
+		return false, "", errors.New("календарь не содержит VTODO")
+	}
+	task, _, err := calendarTask(todo)
+	if err != nil {
+		return false, "", err
+	}
+
+	current, resource, err := s.find(userID, name)
+	switch {
+	case errors.Is(err, ErrItemNotFound):
+		if ifMatch != "" {
+			return false, "", ErrPreconditionFailed
+		}
+		return true, "", s.create(userID, name, todo, task)
+	case err != nil:
+		return false, "", err
+	}
+
+	if ifNoneMatch == "*" || (ifMatch != "" && ifMatch != "*" && ifMatch != caldavItem(current, resource).ETag) {
+		return false, "", ErrPreconditionFailed
+	}
+	task.Id = current.Id
+	if task.Date == "" {
+		task.Date = current.Date
+	}
+	// Правила, которых нет в RRULE, клиент не видит и вернуть не может.
+	if _, ok := dates.ToRRule(current.Date, current.Repeat); todo.Value("RRULE") == "" && current.Repeat != "" && !ok {
+		task.Repeat, task.RepeatCount, task.RepeatUntil = current.Repeat, current.RepeatCount, current.RepeatUntil
+	}
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+	// Выполнение проверяется до правки, чтобы отказ не оставил задачу изменённой наполовину.
+	if itemClosed(todo) {
+		if err := blockedError(current); err != nil {
+			return false, "", err
+		}
+	}
+	if err := s.tasks.EditTask(userID, task); err != nil {
+		return false, "", err
+	}
+	if itemClosed(todo) {
+		if err := s.tasks.DoneTask(userID, task.Id, false); err != nil {
+			return false, "", err
+		}
+	}
+
+	updated, err := s.tasks.GetTask(userID, task.Id)
+	if err != nil {
+		// Выполненная задача без повторений удалена.
+		return false, "", nil
+	}
+	return false, caldavItem(updated, resource).ETag, nil
+}
+
+func (s *CalDAVService) DeleteItem(userID int64, name string, ifMatch string) error {
+	task, resource, err := s.find(userID, name)
+	if err != nil {
+		return err
+	}
+	if ifMatch != "" && ifMatch != "*" && ifMatch != caldavItem(task, resource).ETag {
+		return ErrPreconditionFailed
+	}
+	if err := s.tasks.DeleteTask(userID, task.Id); err != nil {
+		return err
+	}
+	// Задача из корзины после восстановления доступна по имени <id>.ics.
+	if resource.Name != "" {
+		return s.storage.DeleteResource(userID, resource.Name)
+	}
+	return nil
+}
+
+func (s *CalDAVService) create(userID int64, name string, todo *ical.Component, task models.Task) error {
+	// Выполненную задачу сохранять незачем, а ответ 201 без ресурса сбил бы клиента с толку.
+	if itemClosed(todo) {
+		return ErrItemClosed
+	}
+	id, err := s.tasks.AddTask(userID, task)
+	if err != nil {
+		return err
+	}
+	uid := todo.Value("UID")
+	if uid == "" {
+		uid = strings.TrimSuffix(name, ".ics")
+	}
+	return s.storage.AddResource(userID, models.CalDAVResource{Name: name, UID: uid, TaskId: strconv.FormatInt(id, 10)})
+}
+
+// find ищет задачу по имени ресурса: сначала среди имён, заданных клиентами,
+// затем по имени вида <id>.ics.
+func (s *CalDAVService) find(userID int64, name string) (models.Task, models.CalDAVResource, error) {
+	resource, ok, err := s.storage.GetResource(userID, name)
+	if err != nil {
+		return models.Task{}, resource, err
+	}
+	id := resource.TaskId
+	if !ok {
+		id = strings.TrimSuffix(name, ".ics")
+		resources, err := s.storage.GetResources(userID)
+		if err != nil {
+			return models.Task{}, resource, err
+		}
+		if _, named := resources[id]; named || id == name {
+			return models.Task{}, resource, ErrItemNotFound
+		}
+	}
+
+	task, err := s.tasks.GetTask(userID, id)
+	if err != nil {
+		return models.Task{}, resource, ErrItemNotFound
+	}
+	return task, resource, nil
+}
+
+// caldavItem записывает задачу как VTODO. ETag считается без DTSTAMP,
+// чтобы не меняться при каждом запросе.
+func caldavItem(task models.Task, resource models.CalDAVResource) CalDAVItem {
+	item := CalDAVItem{Name: task.Id + ".ics"}
+	uid := "task-" + task.Id
+	if resource.Name != "" {
+		item.Name, uid = resource.Name, resource.UID
+	}
+
+	todo := taskComponent(task, task.Date, uid, true, "")
+	if task.Repeat != "" && task.RepeatCount != 1 {
+		if rule, ok := taskRRule(task); ok {
+			addRRule(todo, task, rule)
+		}
+	}
+	calendar := &ical.Component{Name: "VCALENDAR"}
+	calendar.Add("VERSION", "2.0")
+	calendar.Add("PRODID", "-//Yandex-Practicum//final-project//RU")
+	calendar.Components = []*ical.Component{todo}
+
+	var buf bytes.Buffer
+	calendar.Encode(&buf)
+	sum := sha256.Sum256(buf.Bytes())
+	item.ETag = hex.EncodeToString(sum[:16])
+
+	for i := range todo.Properties {
+		if todo.Properties[i].Name == "DTSTAMP" {
+			todo.Properties[i].Value = time.Now().UTC().Format(ical.DateTimeFormat + "Z")
+		}
+	}
+	buf.Reset()
+	calendar.Encode(&buf)
+	item.Data = buf.String()
+	return item
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/dates"
+	"github.com/Yandex-Practicum/final-project/ical"
+	"github.com/Yandex-Practicum/final-project/models"
+)
+
+// expandYears - на сколько лет вперёд разворачиваются повторения,
+// которые нельзя записать правилом RRULE.
+const expandYears = 1
+
+// CalendarImportResult - итог импорта календаря: созданные, пропущенные
+// и не импортированные из-за ошибок задачи.
+type CalendarImportResult struct {
+	Created int      `json:"created"`
+	Skipped int      `json:"skipped"`
+	Failed  int      `json:"failed"`
+	Errors  []string `json:"errors"`
+}
+
+var icalPriorities = map[models.Priority]int{
+	models.PriorityUrgent: 1,
+	models.PriorityHigh:   3,
+	models.PriorityNormal: 5,
+	models.PriorityLow:    9,
+}
+
+// ExportCalendar возвращает задачи пользователя календарём iCalendar:
+// событиями VEVENT или, если todo, задачами VTODO.
+func (s *TaskService) ExportCalendar(userID int64, todo bool) (*ical.Component, error) {
+	tasks, err := s.storage.AllTasks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Component{Name: "VCALENDAR"}
+	calendar.Add("VERSION", "2.0")
+	calendar.Add("PRODID", "-//Yandex-Practicum//final-project//RU")
+	calendar.Add("CALSCALE", "GREGORIAN")
+	calendar.Add("X-WR-CALNAME", "Задачи")
+
+	stamp := time.Now().UTC().Format(ical.DateTimeFormat + "Z")
+	for _, task := range tasks {
+		calendar.Components = append(calendar.Components, taskComponents(task, todo, stamp)...)
+	}
+	return calendar, nil
+}
+
+// taskComponents записывает повторяющуюся задачу одним компонентом с RRULE, а если
+// правило не переводится в RRULE, - отдельным компонентом на каждую дату.
+func taskComponents(task models.Task, todo bool, stamp string) []*ical.Component {
+	if task.Repeat == "" || task.RepeatCount == 1 {
+		return []*ical.Component{taskComponent(task, task.Date, "task-"+task.Id, todo, stamp)}
+	}
+
+	if rule, ok := taskRRule(task); ok {
+		component := taskComponent(task, task.Date, "task-"+task.Id, todo, stamp)
+		addRRule(component, task, rule)
+		return []*ical.Component{component}
+	}
+
+	components := []*ical.Component{taskComponent(task, task.Date, "task-"+task.Id+"-"+task.Date, todo, stamp)}
+	for _, date := range expandTask(task) {
+		components = append(components, taskComponent(task, date, "task-"+task.Id+"-"+date, todo, stamp))
+	}
+	return components
+}
+
+func taskComponent(task models.Task, date string, uid string, todo bool, stamp string) *ical.Component {
+	component := &ical.Component{Name: "VEVENT"}
+	start := "DTSTART"
+	if todo {
+		component.Name = "VTODO"
+		start = "DUE"
+	}
+
+	component.Add("UID", uid)
+	component.Add("DTSTAMP", stamp)
+	addTaskDate(component, start, task, date)
+	component.Add("SUMMARY", ical.Escape(task.Title))
+	if task.Comment != "" {
+		component.Add("DESCRIPTION", ical.Escape(task.Comment))
+	}
+	if len(task.Tags) > 0 {
+		tags := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			tags = append(tags, ical.Escape(tag))
+		}
+		component.Add("CATEGORIES", strings.Join(tags, ","))
+	}
+	if priority, ok := icalPriorities[task.Priority]; ok {
+		component.Add("PRIORITY", strconv.Itoa(priority))
+	}
+	return component
+}
+
+func addTaskDate(component *ical.Component, name string, task models.Task, date string) {
+	if task.Time == "" {
+		component.AddWithParams(name, map[string]string{"VALUE": "DATE"}, date)
+		return
+	}
+	value := date + "T" + strings.ReplaceAll(task.Time, ":", "") + "00"
+	if task.Timezone == "" {
+		component.Add(name, value)
+	} else {
+		component.AddWithParams(name, map[string]string{"TZID": task.Timezone}, value)
+	}
+}
+
+// addRRule добавляет правило повторения. RRULE отсчитывается от DTSTART,
+// поэтому у VTODO, где дата задачи записана в DUE, добавляется и DTSTART.
+func addRRule(component *ical.Component, task models.Task, rule string) {
+	if component.Name == "VTODO" {
+		addTaskDate(component, "DTSTART", task, task.Date)
+	}
+	component.Add("RRULE", rule)
+}
+
+// taskRRule дополняет правило задачи её окончанием повторений.
+func taskRRule(task models.Task) (string, bool) {
+	rule, ok := dates.ToRRule(task.Date, task.Repeat)
+	if !ok {
+		return "", false
+	}
+	if task.RepeatCount == 0 && task.RepeatUntil == "" {
+		return rule, true
+	}
+	// Два ограничения в одном RRULE не записать.
+	if strings.Contains(rule, "COUNT=") || strings.Contains(rule, "UNTIL=") {
+		return "", false
+	}
+
+	if task.RepeatCount > 0 {
+		return rule + ";COUNT=" + strconv.Itoa(task.RepeatCount), true
+	}
+	switch {
+	case task.Time == "":
+		return rule + ";UNTIL=" + task.RepeatUntil, true
+	case task.Timezone == "":
+		return rule + ";UNTIL=" + task.RepeatUntil + "T235959", true
+	}
+	loc, err := time.LoadLocation(task.Timezone)
+	if err != nil {
+		return "", false
+	}
+	until, err := dates.RRuleUntil(task.RepeatUntil, loc)
+	if err != nil {
+		return "", false
+	}
+	return rule + ";UNTIL=" + until, true
+}
+
+// expandTask возвращает даты повторений задачи после task.Date на expandYears вперёд.
+func expandTask(task models.Task) []string {
+	start, err := time.Parse(dates.TimeFormat, task.Date)
+	if err != nil {
+		return nil
+	}
+	until := start.AddDate(expandYears, 0, 0).Format(dates.TimeFormat)
+	if task.RepeatUntil != "" && task.RepeatUntil < until {
+		until = task.RepeatUntil
+	}
+	count := 0
+	if task.RepeatCount > 0 {
+		count = min(task.RepeatCount-1, dates.MaxOccurrences)
+	}
+
+	occurrences, err := dates.Occurrences(start, task.Date, task.Repeat, count, until)
+	if err != nil {
+		return nil
+	}
+	return occurrences
+}
+
+// ImportCalendar создаёт задачи из VTODO и VEVENT календаря. Выполненные и отменённые
+// задачи, прошедшие события и уже существующие задачи пропускаются.
+func (s *TaskService) ImportCalendar(userID int64, r io.Reader) (CalendarImportResult, error) {
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return CalendarImportResult{}, err
+	}
+
+	result := CalendarImportResult{Errors: []string{}}
+	for _, item := range append(calendar.Find("VTODO"), calendar.Find("VEVENT")...) {
+		if itemClosed(item) {
+			result.Skipped++
+			continue
+		}
+		task, skip, err := calendarTask(item)
+		if err == nil && !skip {
+			skip, err = s.importTask(userID, task)
+		}
+		switch {
+		case err != nil:
+			result.Failed++
+			result.Errors = append(result.Errors, itemName(item)+": "+err.Error())
+		case skip:
+			result.Skipped++
+		default:
+			result.Created++
+		}
+	}
+	return result, nil
+}
+
+func (s *TaskService) importTask(userID int64, task models.Task) (bool, error) {
+	if err := prepareTask(&task); err != nil {
+		if errors.Is(err, dates.ErrRepeatEnded) {
+			return true, nil
+		}
+		return false, err
+	}
+	exists, err := s.storage.HasTask(userID, task.Title, task.Date)
+	if err != nil || exists {
+		return exists, err
+	}
+	_, err = s.AddTask(userID, task)
+	return false, err
+}
+
+// calendarTask переводит VTODO или VEVENT в задачу; skip - компонент импортировать не нужно.
+func calendarTask(item *ical.Component) (task models.Task, skip bool, err error) {
+	if _, ok := item.Get("RECURRENCE-ID"); ok {
+		return task, true, nil
+	}
+
+	task.Title = strings.TrimSpace(ical.Unescape(item.Value("SUMMARY")))
+	if task.Title == "" {
+		return task, false, errors.New("не указан заголовок SUMMARY")
+	}
+	task.Comment = ical.Unescape(item.Value("DESCRIPTION"))
+
+	start, ok := item.Get("DTSTART")
+	if item.Name == "VTODO" {
+		if due, hasDue := item.Get("DUE"); hasDue {
+			start, ok = due, true
+		}
+	}
+	if !ok && item.Name == "VEVENT" {
+		return task, false, errors.New("у события нет DTSTART")
+	}
+	if ok {
+		t, err := start.Time()
+		if err != nil {
+			return task, false, err
+		}
+		task.Date = t.Format(dates.TimeFormat)
+		if !start.IsDate() {
+			task.Time = t.Format(dates.ClockFormat)
+			switch {
+			case strings.HasSuffix(start.Value, "Z"):
+				task.Timezone = "UTC"
+			case start.Params["TZID"] != "":
+				task.Timezone = start.Params["TZID"]
+			}
+		}
+	}
+
+	if rule := item.Value("RRULE"); rule != "" {
+		if task.Date == "" {
+			return task, false, errors.New("правило повторения указано без даты")
+		}
+		rule, ended, err := resumeSeries(&task, rule)
+		if err != nil {
+			return task, false, fmt.Errorf("неподдерживаемое правило повторения: %w", err)
+		}
+		if ended {
+			return task, true, nil
+		}
+		task.Repeat, task.RepeatCount, task.RepeatUntil, err = dates.FromRRule(rule, task.Date)
+		if err != nil {
+			return task, false, fmt.Errorf("неподдерживаемое правило повторения: %w", err)
+		}
+	} else if item.Name == "VEVENT" {
+		now, err := taskNow(task)
+		if err != nil {
+			return task, false, err
+		}
+		if task.Date < now.Format(dates.TimeFormat) {
+			return task, true, nil
+		}
+	}
+
+	if priority, err := strconv.Atoi(item.Value("PRIORITY")); err == nil {
+		task.Priority = calendarPriority(priority)
+	}
+	for _, prop := range item.Properties {
+		if prop.Name != "CATEGORIES" {
+			continue
+		}
+		for _, tag := range strings.Split(prop.Value, ",") {
+			if tag = strings.TrimSpace(ical.Unescape(tag)); tag != "" {
+				task.Tags = append(task.Tags, tag)
+			}
+		}
+	}
+	return task, false, nil
+}
+
+// resumeSeries переносит дату начавшейся в прошлом серии на первое повторение
+// не раньше сегодняшнего дня и вычитает прошедшие повторения из COUNT.
+// ended - повторения серии уже закончились.
+func resumeSeries(task *models.Task, rule string) (string, bool, error) {
+	now, err := taskNow(*task)
+	if err != nil {
+		return "", false, err
+	}
+	if task.Date >= now.Format(dates.TimeFormat) {
+		return rule, false, nil
+	}
+
+	next, err := dates.NextDate(now.AddDate(0, 0, -1), task.Date, rule)
+	if errors.Is(err, dates.ErrRepeatEnded) {
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	rule, count, err := dates.TakeRRuleCount(task.Date, next, rule)
+	if err != nil {
+		return "", false, err
+	}
+	if count > 0 {
+		rule += ";COUNT=" + strconv.Itoa(count)
+	}
+	task.Date = next
+	return rule, false, nil
+}
+
+// itemClosed проверяет, выполнена или отменена задача календаря.
+func itemClosed(item *ical.Component) bool {
+	if _, ok := item.Get("COMPLETED"); ok {
+		return true
+	}
+	status := strings.ToUpper(item.Value("STATUS"))
+	return status == "COMPLETED" || status == "CANCELLED"
+}
+
+// calendarPriority переводит PRIORITY (1 - наивысший, 9 - низший, 0 - не задан) в приоритет задачи.
+func calendarPriority(priority int) models.Priority {
+	switch {
+	case priority >= 1 && priority <= 2:
+		return models.PriorityUrgent
+	case priority >= 3 && priority <= 4:
+		return models.PriorityHigh
+	case priority >= 6 && priority <= 9:
+		return models.PriorityLow
+	}
+	return models.PriorityNormal
+}
+
+func itemName(item *ical.Component) string {
+	if summary := ical.Unescape(item.Value("SUMMARY")); summary != "" {
+		return summary
+	}
+	if uid := item.Value("UID"); uid != "" {
+		return uid
+	}
+	return item.Name
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const (
+	maxChecklistItems  = 100
+	maxChecklistLength = 128
+)
+
+// ChecklistService ведёт чек-листы задач, после каждого изменения подписчики
+// получают событие task.updated.
+type ChecklistService struct {
+	storage *storage.ChecklistStorage
+	tasks   *TaskService
+}
+
+func NewChecklistService(storage *storage.ChecklistStorage, tasks *TaskService) *ChecklistService {
+	return &ChecklistService{storage: storage, tasks: tasks}
+}
+
+func (s *ChecklistService) AddItem(userID int64, item models.ChecklistItem) (int64, error) {
+	if item.TaskId == "" {
+		return 0, errors.New("не указан идентификатор задачи")
+	}
+	if err := validateChecklistTitle(&item); err != nil {
+		return 0, err
+	}
+	count, err := s.storage.CountItems(userID, item.TaskId)
+	if err != nil {
+		return 0, err
+	}
+	if count >= maxChecklistItems {
+		return 0, fmt.Errorf("в чек-листе может быть не больше %d пунктов", maxChecklistItems)
+	}
+	id, err := s.storage.AddItem(userID, item)
+	if err != nil {
+		return 0, err
+	}
+	s.tasks.publish(userID, EventTaskUpdated, item.TaskId)
+	return id, nil
+}
+
+func (s *ChecklistService) GetItems(userID int64, taskID string) ([]models.ChecklistItem, error) {
+	return s.storage.GetItems(userID, taskID)
+}
+
+func (s *ChecklistService) EditItem(userID int64, item models.ChecklistItem) error {
+	if err := validateChecklistTitle(&item); err != nil {
+		return err
+	}
+	return s.changeItem(userID, item.Id, func() error {
+		return s.storage.EditItem(userID, item)
+	})
+}
+
+func (s *ChecklistService) CheckItem(userID int64, id string, done bool) error {
+	return s.changeItem(userID, id, func() error {
+		return s.storage.SetDone(userID, id, done)
+	})
+}
+
+func (s *ChecklistService) DeleteItem(userID int64, id string) error {
+	return s.changeItem(userID, id, func() error {
+		return s.storage.DeleteItem(userID, id)
+	})
+}
+
+// changeItem выполняет изменение пункта id и сообщает об изменении его задачи.
+// Задача определяется заранее, потому что после удаления пункта её уже не найти.
+func (s *ChecklistService) changeItem(userID int64, id string, change func() error) error {
+	taskID, err := s.storage.ItemTaskID(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	s.tasks.publish(userID, EventTaskUpdated, taskID)
+	return nil
+}
+
+func (s *ChecklistService) Reorder(userID int64, order models.ChecklistOrder) error {
+	if order.TaskId == "" {
+		return errors.New("не указан идентификатор задачи")
+	}
+	seen := make(map[string]bool)
+	for _, id := range order.Ids {
+		if seen[id] {
+			return errors.New("пункт чек-листа указан дважды")
+		}
+		seen[id] = true
+	}
+	if err := s.storage.Reorder(userID, order); err != nil {
+		return err
+	}
+	s.tasks.publish(userID, EventTaskUpdated, order.TaskId)
+	return nil
+}
+
+func validateChecklistTitle(item *models.ChecklistItem) error {
+	item.Title = strings.TrimSpace(item.Title)
+	if item.Title == "" {
+		return errors.New("не указан текст пункта чек-листа")
+	}
+	if utf8.RuneCountInString(item.Title) > maxChecklistLength {
+		return fmt.Errorf("пункт чек-листа длиннее %d символов", maxChecklistLength)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidQuery = errors.New("неверные параметры запроса")
+
+// taskCursor хранит позицию в списке задач. Клиент получает его
+// как непрозрачную строку и передаёт обратно параметром cursor.
+type taskCursor struct {
+	Sort  string   `json:"s"`
+	Order string   `json:"o"`
+	After []string `json:"a"`
+}
+
+func encodeCursor(cursor taskCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string) (taskCursor, error) {
+	var cursor taskCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: неверный курсор", ErrInvalidQuery)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.After) == 0 {
+		return cursor, fmt.Errorf("%w: неверный курсор", ErrInvalidQuery)
+	}
+	return cursor, nil
+}
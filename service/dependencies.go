@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+var ErrTaskBlocked = errors.New("задача ждёт выполнения других задач")
+
+// DependencyService связывает задачи, о новой и удалённой связи подписчики
+// узнают из события task.updated зависимой задачи.
+type DependencyService struct {
+	storage *storage.DependencyStorage
+	tasks   *TaskService
+}
+
+func NewDependencyService(storage *storage.DependencyStorage, tasks *TaskService) *DependencyService {
+	return &DependencyService{storage: storage, tasks: tasks}
+}
+
+func (s *DependencyService) AddDependency(userID int64, dependency models.Dependency) error {
+	if dependency.TaskId == "" || dependency.DependsOn == "" {
+		return errors.New("не указан идентификатор задачи")
+	}
+	if dependency.TaskId == dependency.DependsOn {
+		return errors.New("задача не может зависеть от самой себя")
+	}
+
+	dependencies, err := s.storage.GetDependencies(userID)
+	if err != nil {
+		return err
+	}
+	if reachable(dependencies, dependency.DependsOn, dependency.TaskId) {
+		return errors.New("зависимость образует цикл")
+	}
+	if err := s.storage.AddDependency(userID, dependency); err != nil {
+		return err
+	}
+	s.tasks.publish(userID, EventTaskUpdated, dependency.TaskId)
+	return nil
+}
+
+func (s *DependencyService) DeleteDependency(userID int64, dependency models.Dependency) error {
+	if err := s.storage.DeleteDependency(userID, dependency); err != nil {
+		return err
+	}
+	s.tasks.publish(userID, EventTaskUpdated, dependency.TaskId)
+	return nil
+}
+
+// reachable проверяет, зависит ли задача from от задачи to напрямую или через другие задачи.
+func reachable(dependencies []models.Dependency, from, to string) bool {
+	prerequisites := make(map[string][]string)
+	for _, d := range dependencies {
+		prerequisites[d.TaskId] = append(prerequisites[d.TaskId], d.DependsOn)
+	}
+
+	visited := map[string]bool{from: true}
+	stack := []string{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range prerequisites[id] {
+			if next == to {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
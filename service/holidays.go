@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/dates"
+	"github.com/Yandex-Practicum/final-project/ical"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+type HolidayService struct {
+	storage *storage.HolidayStorage
+
+	mu    sync.RWMutex
+	dates map[string]bool
+}
+
+type ImportResult struct {
+	Added   int      `json:"added"`
+	Skipped int      `json:"skipped"`
+	Errors  []string `json:"errors"`
+}
+
+func NewHolidayService(storage *storage.HolidayStorage) *HolidayService {
+	return &HolidayService{storage: storage, dates: map[string]bool{}}
+}
+
+// Load перечитывает праздники из БД; сервис реализует dates.Calendar.
+func (s *HolidayService) Load() error {
+	holidays, err := s.storage.GetHolidays()
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]bool, len(holidays))
+	for _, holiday := range holidays {
+		loaded[holiday.Date] = true
+	}
+
+	s.mu.Lock()
+	s.dates = loaded
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *HolidayService) IsHoliday(date time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dates[date.Format(dates.TimeFormat)]
+}
+
+func (s *HolidayService) AddHoliday(holiday models.Holiday) (int64, error) {
+	if err := s.validate(holiday); err != nil {
+		return 0, err
+	}
+
+	id, err := s.storage.AddHoliday(holiday)
+	if err != nil {
+		return 0, err
+	}
+	return id, s.Load()
+}
+
+func (s *HolidayService) EditHoliday(holiday models.Holiday) error {
+	if holiday.Id == "" {
+		return errors.New("не указан идентификатор праздника")
+	}
+	current, err := s.storage.GetHoliday(holiday.Id)
+	if err != nil {
+		return err
+	}
+	if current.Date != holiday.Date {
+		if err := s.validate(holiday); err != nil {
+			return err
+		}
+	}
+
+	err = s.storage.EditHoliday(holiday)
+	if err != nil {
+		return err
+	}
+	return s.Load()
+}
+
+func (s *HolidayService) GetHolidays() ([]models.Holiday, error) {
+	return s.storage.GetHolidays()
+}
+
+func (s *HolidayService) DeleteHoliday(id string) error {
+	err := s.storage.DeleteHoliday(id)
+	if err != nil {
+		return err
+	}
+	return s.Load()
+}
+
+func (s *HolidayService) ImportHolidays(r io.Reader) (ImportResult, error) {
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result := ImportResult{Errors: []string{}}
+	for _, event := range calendar.Find("VEVENT") {
+		days, err := eventDays(event)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		for _, day := range days {
+			added, err := s.storage.AddHolidayIfMissing(models.Holiday{
+				Date:  day,
+				Title: ical.Unescape(event.Value("SUMMARY")),
+			})
+			if err != nil {
+				return result, err
+			}
+			if added {
+				result.Added++
+			} else {
+				result.Skipped++
+			}
+		}
+	}
+	return result, s.Load()
+}
+
+func (s *HolidayService) validate(holiday models.Holiday) error {
+	if _, err := time.Parse(dates.TimeFormat, holiday.Date); err != nil {
+		return errors.New("неправильный формат даты")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dates[holiday.Date] {
+		return errors.New("праздник на эту дату уже добавлен")
+	}
+	return nil
+}
+
+func eventDays(event *ical.Component) ([]string, error) {
+	startProp, ok := event.Get("DTSTART")
+	if !ok {
+		return nil, errors.New("у события нет DTSTART")
+	}
+	start, err := startProp.Time()
+	if err != nil {
+		return nil, err
+	}
+
+	days := []string{start.Format(dates.TimeFormat)}
+	endProp, ok := event.Get("DTEND")
+	if !ok || !endProp.IsDate() {
+		return days, nil
+	}
+	end, err := endProp.Time()
+	if err != nil {
+		return nil, err
+	}
+	for day := start.AddDate(0, 0, 1); day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(dates.TimeFormat))
+	}
+	return days, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const maxProjectName = 64
+
+var projectColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type ProjectService struct {
+	storage *storage.ProjectStorage
+}
+
+func NewProjectService(storage *storage.ProjectStorage) *ProjectService {
+	return &ProjectService{storage: storage}
+}
+
+func (s *ProjectService) AddProject(userID int64, project models.Project) (int64, error) {
+	if err := validateProject(&project); err != nil {
+		return 0, err
+	}
+	if _, err := s.storage.DefaultProject(userID); err != nil {
+		return 0, err
+	}
+	id, err := s.storage.AddProject(userID, project)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE") {
+		return 0, errors.New("проект с таким названием уже есть")
+	}
+	return id, err
+}
+
+// GetProjects возвращает проекты пользователя, проект по умолчанию первым.
+func (s *ProjectService) GetProjects(userID int64, archived bool) ([]models.Project, error) {
+	if _, err := s.storage.DefaultProject(userID); err != nil {
+		return nil, err
+	}
+	return s.storage.GetProjects(userID, archived)
+}
+
+func (s *ProjectService) GetProject(userID int64, id string) (models.Project, error) {
+	return s.storage.GetProject(userID, id)
+}
+
+func (s *ProjectService) EditProject(userID int64, project models.Project) error {
+	if err := validateProject(&project); err != nil {
+		return err
+	}
+	current, err := s.storage.GetProject(userID, project.Id)
+	if err != nil {
+		return err
+	}
+	if current.Default && project.Archived {
+		return errors.New("проект по умолчанию нельзя архивировать")
+	}
+	err = s.storage.EditProject(userID, project)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE") {
+		return errors.New("проект с таким названием уже есть")
+	}
+	return err
+}
+
+// DeleteProject удаляет проект, задачи из него переходят в проект по умолчанию.
+func (s *ProjectService) DeleteProject(userID int64, id string) error {
+	defaultProject, err := s.storage.DefaultProject(userID)
+	if err != nil {
+		return err
+	}
+	if defaultProject.Id == id {
+		return errors.New("проект по умолчанию нельзя удалить")
+	}
+	return s.storage.DeleteProject(userID, id, defaultProject.Id)
+}
+
+func validateProject(project *models.Project) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if project.Name == "" {
+		return errors.New("не указано название проекта")
+	}
+	if utf8.RuneCountInString(project.Name) > maxProjectName {
+		return fmt.Errorf("название проекта длиннее %d символов", maxProjectName)
+	}
+	if project.Color != "" && !projectColor.MatchString(project.Color) {
+		return errors.New("цвет проекта задаётся в формате #RRGGBB")
+	}
+	project.Color = strings.ToLower(project.Color)
+	return nil
+}
@@ -1,7 +1,12 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Yandex-Practicum/final-project/dates"
@@ -10,67 +15,479 @@ import (
 )
 
 type TaskService struct {
-	storage *storage.TaskStorage
+	storage     *storage.TaskStorage
+	completions *storage.CompletionStorage
+	projects    *storage.ProjectStorage
+	webhooks    *WebhookService
 }
 
-func NewTaskService(storage *storage.TaskStorage) *TaskService {
-	return &TaskService{storage: storage}
+func NewTaskService(
+	storage *storage.TaskStorage,
+	completions *storage.CompletionStorage,
+	projects *storage.ProjectStorage,
+	webhooks *WebhookService,
+) *TaskService {
+	return &TaskService{storage: storage, completions: completions, projects: projects, webhooks: webhooks}
 }
 
-func (s *TaskService) AddTask(task models.Task) (int64, error) {
+func (s *TaskService) AddTask(userID int64, task models.Task) (int64, error) {
+	task.Id = ""
+	return s.addTask(userID, task)
+}
+
+// addTask добавляет задачу, непустой task.Id сохраняется как её идентификатор.
+func (s *TaskService) addTask(userID int64, task models.Task) (int64, error) {
 	if task.Title == "" {
 		return 0, errors.New("не указан заголовок задачи")
 	}
+	if task.Priority == "" {
+		task.Priority = models.PriorityNormal
+	}
+
+	if err := prepareTask(&task); err != nil {
+		return 0, err
+	}
+	if task.ProjectId == "" {
+		project, err := s.projects.DefaultProject(userID)
+		if err != nil {
+			return 0, err
+		}
+		task.ProjectId = project.Id
+	} else if err := s.checkProject(userID, task.ProjectId); err != nil {
+		return 0, err
+	}
+
+	id, err := s.storage.AddTask(userID, task)
+	if err != nil {
+		return 0, err
+	}
+	s.publish(userID, EventTaskCreated, strconv.FormatInt(id, 10))
+	return id, nil
+}
+
+func (s *TaskService) EditTask(userID int64, task models.Task) error {
+	if task.Title == "" {
+		return errors.New("заголовок не может быть пустым")
+	}
+	if err := prepareTask(&task); err != nil {
+		return err
+	}
+	if task.ProjectId != "" {
+		if err := s.checkProject(userID, task.ProjectId); err != nil {
+			return err
+		}
+	}
+	if err := s.storage.EditTask(userID, task); err != nil {
+		return err
+	}
+	s.publish(userID, EventTaskUpdated, task.Id)
+	return nil
+}
+
+// KeepOmitted переносит в изменённую задачу время, часовой пояс и окончание повторений,
+// которых нет среди присланных полей fields: старые клиенты знают только id, date, title,
+// comment и repeat. Окончание не переносится, если правило убрано или само задаёт COUNT или UNTIL.
+func (s *TaskService) KeepOmitted(userID int64, task *models.Task, fields map[string]json.RawMessage) error {
+	current, err := s.storage.GetTask(userID, task.Id)
+	if err != nil {
+		return err
+	}
+	if _, ok := fields["time"]; !ok {
+		task.Time = current.Time
+	}
+	if _, ok := fields["timezone"]; !ok {
+		task.Timezone = current.Timezone
+	}
+
+	_, hasUntil := fields["repeat_until"]
+	_, hasCount := fields["repeat_count"]
+	if !hasUntil && !hasCount && task.Repeat != "" && !ruleEnds(task.Repeat) {
+		task.RepeatUntil = current.RepeatUntil
+		task.RepeatCount = current.RepeatCount
+	}
+	return nil
+}
+
+// ruleEnds - правило RRULE само задаёт окончание повторений.
+func ruleEnds(repeat string) bool {
+	rule := strings.ToUpper(repeat)
+	return dates.IsRRule(repeat) && (strings.Contains(rule, "COUNT=") || strings.Contains(rule, "UNTIL="))
+}
+
+// MoveTask переносит задачу в другой проект пользователя.
+func (s *TaskService) MoveTask(userID int64, id string, projectID string) error {
+	if err := s.checkProject(userID, projectID); err != nil {
+		return err
+	}
+	if err := s.storage.MoveTask(userID, id, projectID); err != nil {
+		return err
+	}
+	s.publish(userID, EventTaskUpdated, id)
+	return nil
+}
+
+func (s *TaskService) checkProject(userID int64, projectID string) error {
+	project, err := s.projects.GetProject(userID, projectID)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return errors.New("проект в архиве")
+	}
+	return nil
+}
+
+// DoneTask записывает выполнение в историю, затем переносит задачу
+// на следующую дату по правилу или удаляет её, если повторений больше нет.
+// Задачу с невыполненными предварительными задачами можно выполнить только с force.
+func (s *TaskService) DoneTask(userID int64, id string, force bool) error {
+	task, err := s.storage.GetTask(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := blockedError(task); err != nil && !force {
+		return err
+	}
+
+	now, err := taskNow(task)
+	if err != nil {
+		return err
+	}
+	completion := models.Completion{
+		TaskId:      task.Id,
+		Date:        task.Date,
+		Title:       task.Title,
+		Comment:     task.Comment,
+		Repeat:      task.Repeat,
+		RepeatUntil: task.RepeatUntil,
+		RepeatCount: task.RepeatCount,
+		Time:        task.Time,
+		Timezone:    task.Timezone,
+		Priority:    task.Priority,
+		ProjectId:   task.ProjectId,
+		Tags:        task.Tags,
+		Checklist:   task.Checklist,
+		CompletedOn: now.Format(dates.TimeFormat),
+		CompletedAt: now.Format(time.RFC3339),
+	}
+
+	next, err := nextOccurrence(now, task)
+	if err != nil {
+		return err
+	}
+	completionID, err := s.completions.CompleteTask(userID, completion, next)
+	if err != nil {
+		return err
+	}
+
+	if s.webhooks != nil {
+		completion.Id = strconv.FormatInt(completionID, 10)
+		data := map[string]any{"task": task, "completion": completion}
+		if next != nil {
+			data["next_date"] = next.Date
+		}
+		s.webhooks.Publish(userID, EventTaskCompleted, data)
+	}
+	return nil
+}
+
+// blockedError возвращает ErrTaskBlocked со списком невыполненных предварительных задач.
+func blockedError(task models.Task) error {
+	if len(task.BlockedBy) == 0 {
+		return nil
+	}
+	titles := make([]string, 0, len(task.BlockedBy))
+	for _, ref := range task.BlockedBy {
+		titles = append(titles, ref.Title)
+	}
+	return fmt.Errorf("%w: %s", ErrTaskBlocked, strings.Join(titles, ", "))
+}
+
+// nextOccurrence возвращает задачу со следующей датой повторения
+// или nil, если повторений больше нет.
+func nextOccurrence(now time.Time, task models.Task) (*models.Task, error) {
+	if task.Repeat == "" || task.RepeatCount == 1 {
+		return nil, nil
+	}
+
+	nextDate, err := dates.NextDate(now, task.Date, task.Repeat)
+	if errors.Is(err, dates.ErrRepeatEnded) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка вычисления следующей даты: %w", err)
+	}
+	if task.RepeatUntil != "" && nextDate > task.RepeatUntil {
+		return nil, nil
+	}
+
+	task.Date = nextDate
+	if task.RepeatCount > 0 {
+		task.RepeatCount--
+	}
+	return &task, nil
+}
+
+// UndoneTask отменяет последнее выполнение задачи. Если задача была удалена
+// выполнением или лежала в корзине, подписчики получают task.created, иначе task.updated.
+func (s *TaskService) UndoneTask(userID int64, id string) (models.Completion, error) {
+	_, err := s.storage.GetTask(userID, id)
+	event := EventTaskUpdated
+	if err != nil {
+		event = EventTaskCreated
+	}
+	completion, err := s.completions.UndoCompletion(userID, id)
+	if err != nil {
+		return completion, err
+	}
+	s.publish(userID, event, completion.TaskId)
+	return completion, nil
+}
+
+func (s *TaskService) GetCompletions(userID int64, query models.CompletionQuery) ([]models.Completion, error) {
+	for _, date := range []string{query.From, query.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dates.TimeFormat, date); err != nil {
+			return nil, fmt.Errorf("%w: неправильный формат даты %s", ErrInvalidQuery, date)
+		}
+	}
+	if query.Limit < 1 {
+		return nil, fmt.Errorf("%w: недопустимое число записей", ErrInvalidQuery)
+	}
+	return s.completions.GetCompletions(userID, query)
+}
+
+func (s *TaskService) GetTask(userID int64, id string) (models.Task, error) {
+	return s.storage.GetTask(userID, id)
+}
+
+// GetTasks возвращает страницу задач и курсор следующей страницы.
+// Пустой курсор означает, что страница последняя.
+func (s *TaskService) GetTasks(userID int64, query models.TaskQuery) ([]models.Task, string, error) {
+	if err := prepareTaskQuery(&query); err != nil {
+		return nil, "", err
+	}
+
+	var after []string
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Sort != query.Sort || cursor.Order != query.Order ||
+			len(cursor.After) != len(storage.TaskSortValues(models.Task{}, query.Sort)) {
+			return nil, "", fmt.Errorf("%w: курсор получен для другой сортировки", ErrInvalidQuery)
+		}
+		after = cursor.After
+	}
+
+	limit := query.Limit
+	query.Limit++
+	tasks, err := s.storage.GetTasks(userID, query, after)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tasks) <= limit {
+		return tasks, "", nil
+	}
+
+	tasks = tasks[:limit]
+	next, err := encodeCursor(taskCursor{
+		Sort:  query.Sort,
+		Order: query.Order,
+		After: storage.TaskSortValues(tasks[limit-1], query.Sort),
+	})
+	return tasks, next, err
+}
+
+func (s *TaskService) SearchTasks(userID int64, search string, limit int) ([]models.Task, error) {
+	date, err := time.Parse("02.01.2006", search)
+	if err == nil {
+		return s.storage.SearchByDate(userID, date.Format(dates.TimeFormat), limit)
+	}
+	return s.storage.SearchByText(userID, search, limit)
+}
+
+func (s *TaskService) DeleteTask(userID int64, id string) error {
+	task, err := s.storage.GetTask(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.DeleteTask(userID, id, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if s.webhooks != nil {
+		s.webhooks.Publish(userID, EventTaskDeleted, map[string]any{"task": task})
+	}
+	return nil
+}
+
+// publish отправляет подписчикам событие с текущим состоянием задачи.
+func (s *TaskService) publish(userID int64, event string, id string) {
+	if s.webhooks == nil {
+		return
+	}
+	task, err := s.storage.GetTask(userID, id)
+	if err != nil {
+		log.Printf("не удалось прочитать задачу %s для события %s: %v", id, event, err)
+		return
+	}
+	s.webhooks.Publish(userID, event, map[string]any{"task": task})
+}
+
+func prepareTaskQuery(query *models.TaskQuery) error {
+	if query.Sort == "" {
+		query.Sort = "date"
+	}
+	if !storage.IsTaskSort(query.Sort) {
+		return fmt.Errorf("%w: неизвестная сортировка %s", ErrInvalidQuery, query.Sort)
+	}
+	if query.Order == "" {
+		query.Order = "desc"
+	}
+	if query.Order != "asc" && query.Order != "desc" {
+		return fmt.Errorf("%w: порядок сортировки должен быть asc или desc", ErrInvalidQuery)
+	}
+	for _, date := range []string{query.From, query.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dates.TimeFormat, date); err != nil {
+			return fmt.Errorf("%w: неправильный формат даты %s", ErrInvalidQuery, date)
+		}
+	}
+	if query.From != "" && query.To != "" && query.From > query.To {
+		return fmt.Errorf("%w: начало периода позже его окончания", ErrInvalidQuery)
+	}
+	if query.Limit < 1 {
+		return fmt.Errorf("%w: недопустимое число задач на странице", ErrInvalidQuery)
+	}
+	for i, priority := range query.Priorities {
+		query.Priorities[i] = models.Priority(strings.ToLower(string(priority)))
+		if !query.Priorities[i].Valid() {
+			return fmt.Errorf("%w: неизвестный приоритет", ErrInvalidQuery)
+		}
+	}
+	tags, err := normalizeTags(query.Tags)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, err)
+	}
+	query.Tags = tags
+	if query.TagsMode == "" {
+		query.TagsMode = "or"
+	}
+	if query.TagsMode != "and" && query.TagsMode != "or" {
+		return fmt.Errorf("%w: tags_mode должен быть and или or", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// prepareTask проверяет поля задачи и переносит просроченную дату
+// на сегодня (или следующую по правилу) в часовом поясе задачи.
+func prepareTask(task *models.Task) error {
+	if err := validateTime(*task); err != nil {
+		return err
+	}
+	if err := validateRepeatEnd(*task); err != nil {
+		return err
+	}
+	task.Priority = models.Priority(strings.ToLower(string(task.Priority)))
+	if task.Priority != "" && !task.Priority.Valid() {
+		return errors.New("неизвестный приоритет, допустимы low, normal, high, urgent")
+	}
+	tags, err := normalizeTags(task.Tags)
+	if err != nil {
+		return err
+	}
+	task.Tags = tags
+
+	now, err := taskNow(*task)
+	if err != nil {
+		return err
+	}
+	currentDate := now.Format(dates.TimeFormat)
 
 	if task.Date == "" {
-		task.Date = time.Now().Format(dates.TimeFormat)
+		task.Date = currentDate
 	} else {
 		if _, err := time.Parse(dates.TimeFormat, task.Date); err != nil {
-			return 0, errors.New("неправильный формат даты")
+			return errors.New("неправильный формат даты")
 		}
 	}
 
-	now := time.Now()
-	currentDate := now.Format(dates.TimeFormat)
-
+	start := task.Date
 	if task.Date < currentDate {
 		if task.Repeat == "" {
 			task.Date = currentDate
 		} else {
 			nextDate, err := dates.NextDate(now, task.Date, task.Repeat)
 			if err != nil {
-				return 0, err
+				return err
 			}
 			task.Date = nextDate
 		}
 	}
-
-	return s.storage.AddTask(task)
-}
-
-func (s *TaskService) EditTask(task models.Task) error {
-	if task.Title == "" {
-		return errors.New("заголовок не может быть пустым")
+	if dates.IsRRule(task.Repeat) {
+		// COUNT из RRULE хранится в repeat_count: его уменьшает каждое выполнение.
+		repeat, count, err := dates.TakeRRuleCount(start, task.Date, task.Repeat)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			if task.RepeatUntil != "" || task.RepeatCount != 0 {
+				return errors.New("нельзя одновременно указать COUNT в правиле и окончание повторений")
+			}
+			task.Repeat = repeat
+			task.RepeatCount = count
+		}
 	}
-	return s.storage.EditTask(task)
-}
 
-func (s *TaskService) GetTask(id string) (models.Task, error) {
-	return s.storage.GetTask(id)
+	if task.RepeatUntil != "" && task.Date > task.RepeatUntil {
+		return errors.New("дата задачи позже окончания повторений")
+	}
+	return nil
 }
 
-func (s *TaskService) GetTasks(limit int) ([]models.Task, error) {
-	return s.storage.GetTasks(limit)
+func taskNow(task models.Task) (time.Time, error) {
+	if task.Timezone == "" {
+		return time.Now(), nil
+	}
+	loc, err := time.LoadLocation(task.Timezone)
+	if err != nil {
+		return time.Time{}, errors.New("неизвестный часовой пояс")
+	}
+	return time.Now().In(loc), nil
 }
 
-func (s *TaskService) SearchTasks(search string) ([]models.Task, error) {
-	date, err := time.Parse("02.01.2006", search)
-	if err == nil {
-		return s.storage.SearchByDate(date.Format(dates.TimeFormat))
+func validateTime(task models.Task) error {
+	if task.Time == "" {
+		return nil
+	}
+	if _, err := time.Parse(dates.ClockFormat, task.Time); err != nil || len(task.Time) != len(dates.ClockFormat) {
+		return errors.New("неправильный формат времени")
 	}
-	return s.storage.SearchByText(search)
+	return nil
 }
 
-func (s *TaskService) DeleteTask(id string) error {
-	return s.storage.DeleteTask(id)
+func validateRepeatEnd(task models.Task) error {
+	if task.RepeatUntil == "" && task.RepeatCount == 0 {
+		return nil
+	}
+	if task.Repeat == "" {
+		return errors.New("окончание повторений указано без правила повторения")
+	}
+	if task.RepeatUntil != "" && task.RepeatCount != 0 {
+		return errors.New("нельзя одновременно указать дату окончания и число повторений")
+	}
+	if task.RepeatCount < 0 {
+		return errors.New("недопустимое число повторений")
+	}
+	if task.RepeatUntil != "" {
+		if _, err := time.Parse(dates.TimeFormat, task.RepeatUntil); err != nil {
+			return errors.New("неправильный формат даты окончания повторений")
+		}
+	}
+	return nil
 }
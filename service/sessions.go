@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/jwt"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+var ErrInvalidRefresh = errors.New("недействительный refresh-токен")
+
+type SessionService struct {
+	storage *storage.SessionStorage
+}
+
+func NewSessionService(storage *storage.SessionStorage) *SessionService {
+	return &SessionService{storage: storage}
+}
+
+func (s *SessionService) Start(userID int64) (models.LoginResponse, error) {
+	now := time.Now()
+	err := s.storage.DeleteExpired(now.Unix())
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	sessionID, err := jwt.RandomID()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	secret, err := jwt.RandomID()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	err = s.storage.AddSession(models.Session{
+		Id:          sessionID,
+		UserId:      userID,
+		RefreshHash: hashSecret(secret),
+		ExpiresAt:   now.Add(refreshTokenTTL()).Unix(),
+	})
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	return s.issue(userID, sessionID, secret)
+}
+
+// Refresh выдаёт новую пару токенов и делает предъявленный refresh-токен
+// недействительным. Повторное использование старого токена отзывает сессию.
+func (s *SessionService) Refresh(refreshToken string) (models.LoginResponse, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return models.LoginResponse{}, ErrInvalidRefresh
+	}
+	session, err := s.storage.GetSession(sessionID)
+	if err != nil {
+		return models.LoginResponse{}, ErrInvalidRefresh
+	}
+
+	now := time.Now()
+	if session.Revoked || session.ExpiresAt < now.Unix() {
+		return models.LoginResponse{}, ErrInvalidRefresh
+	}
+	oldHash := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(oldHash), []byte(session.RefreshHash)) != 1 {
+		if err := s.storage.RevokeSession(sessionID); err != nil {
+			return models.LoginResponse{}, err
+		}
+		return models.LoginResponse{}, ErrInvalidRefresh
+	}
+
+	newSecret, err := jwt.RandomID()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	rotated, err := s.storage.RotateRefresh(sessionID, oldHash, hashSecret(newSecret), now.Add(refreshTokenTTL()).Unix())
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if !rotated {
+		return models.LoginResponse{}, ErrInvalidRefresh
+	}
+	return s.issue(session.UserId, sessionID, newSecret)
+}
+
+func (s *SessionService) Revoke(sessionID string) error {
+	return s.storage.RevokeSession(sessionID)
+}
+
+// IsRevoked считает отозванной и сессию, которую не удалось прочитать, и сессию
+// другого пользователя: токен действует только в сессии своего пользователя.
+func (s *SessionService) IsRevoked(sessionID string, userID int64) bool {
+	session, err := s.storage.GetSession(sessionID)
+	return err != nil || session.Revoked || session.UserId != userID
+}
+
+func (s *SessionService) issue(userID int64, sessionID string, secret string) (models.LoginResponse, error) {
+	response, err := jwt.JWTCreate(userID, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	response.RefreshToken = sessionID + "." + secret
+	return response, nil
+}
+
+func refreshTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("TODO_REFRESH_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultRefreshTokenTTL
+	}
+	return ttl
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
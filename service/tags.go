@@ -0,0 +1,46 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 32
+)
+
+// normalizeTags приводит теги к нижнему регистру без "#" и убирает повторы.
+// nil остаётся nil, чтобы при редактировании отличать "теги не переданы" от пустого списка.
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" {
+			return nil, errors.New("пустой тег")
+		}
+		if strings.Contains(tag, storage.TagSeparator) {
+			return nil, errors.New("тег не может содержать запятую")
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("тег длиннее %d символов", maxTagLength)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("у задачи может быть не больше %d тегов", maxTags)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/models"
+)
+
+const defaultTrashDays = 30
+
+func (s *TaskService) GetTrash(userID int64, limit int) ([]models.Task, error) {
+	if _, err := s.PurgeExpiredTrash(); err != nil {
+		return nil, err
+	}
+	return s.storage.GetTrash(userID, limit)
+}
+
+func (s *TaskService) RestoreTask(userID int64, id string) error {
+	if err := s.storage.RestoreTask(userID, id); err != nil {
+		return err
+	}
+	s.publish(userID, EventTaskCreated, id)
+	return nil
+}
+
+// PurgeTrash окончательно удаляет задачу id из корзины или всю корзину, если id пуст.
+func (s *TaskService) PurgeTrash(userID int64, id string) (int64, error) {
+	purged, err := s.storage.PurgeTrash(userID, id)
+	if err != nil {
+		return 0, err
+	}
+	if id != "" && purged == 0 {
+		return 0, errors.New("задача не найдена в корзине")
+	}
+	return purged, nil
+}
+
+// PurgeExpiredTrash удаляет задачи, пролежавшие в корзине дольше TODO_TRASH_DAYS дней.
+func (s *TaskService) PurgeExpiredTrash() (int64, error) {
+	days := TrashDays()
+	if days == 0 {
+		return 0, nil
+	}
+	before := time.Now().UTC().AddDate(0, 0, -days).Format(time.RFC3339)
+	return s.storage.PurgeDeletedBefore(before)
+}
+
+// TrashDays возвращает срок хранения задач в корзине, 0 отключает автоочистку.
+func TrashDays() int {
+	value, ok := os.LookupEnv("TODO_TRASH_DAYS")
+	if !ok {
+		return defaultTrashDays
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return defaultTrashDays
+	}
+	return days
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minLoginLength    = 3
+	maxLoginLength    = 64
+	minPasswordLength = 6
+	maxPasswordBytes  = 72
+)
+
+type UserService struct {
+	storage *storage.UserStorage
+}
+
+func NewUserService(storage *storage.UserStorage) *UserService {
+	return &UserService{storage: storage}
+}
+
+func (s *UserService) Register(login models.Login) (int64, error) {
+	length := utf8.RuneCountInString(login.Login)
+	if length < minLoginLength || length > maxLoginLength || strings.ContainsAny(login.Login, " \t\n") {
+		return 0, errors.New("логин должен содержать от 3 до 64 символов без пробелов")
+	}
+	if utf8.RuneCountInString(login.Password) < minPasswordLength || len(login.Password) > maxPasswordBytes {
+		return 0, errors.New("пароль должен содержать от 6 символов и не более 72 байт")
+	}
+
+	exists, err := s.storage.LoginExists(login.Login)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, errors.New("пользователь с таким логином уже существует")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(login.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	return s.storage.AddUser(models.User{Login: login.Login, PasswordHash: string(hash)})
+}
+
+func (s *UserService) Authenticate(login models.Login) (int64, error) {
+	user, err := s.storage.GetUserByLogin(login.Login)
+	if err != nil {
+		return 0, errors.New("неверный логин или пароль")
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(login.Password))
+	if err != nil {
+		return 0, errors.New("неверный логин или пароль")
+	}
+	return user.Id, nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/jwt"
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/Yandex-Practicum/final-project/storage"
+)
+
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskCompleted = "task.completed"
+	EventTaskDeleted   = "task.deleted"
+
+	maxDeliveryAttempts = 5
+	deliveryTimeout     = 10 * time.Second
+	deliveryBatch       = 20
+	// retryBackoff - пауза перед второй попыткой, перед каждой следующей она удваивается.
+	retryBackoff = 30 * time.Second
+	pollInterval = time.Second
+)
+
+var webhookEvents = []string{EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventTaskDeleted}
+
+var errPrivateTarget = errors.New("адрес подписки указывает на внутреннюю сеть")
+
+// WebhookService хранит подписки и доставляет события задач: Publish ставит событие
+// в очередь в БД, а Run отправляет его подписчикам в фоне, повторяя неудачные попытки.
+// События каждой подписки отправляются по порядку: пока раннее событие ждёт повторной
+// попытки, следующие не отправляются. Подписки обслуживаются в отдельных горутинах,
+// так что медленный получатель не задерживает остальных.
+type WebhookService struct {
+	storage      *storage.WebhookStorage
+	client       *http.Client
+	wake         chan struct{}
+	allowPrivate bool
+
+	mu   sync.Mutex
+	busy map[int64]bool
+}
+
+func NewWebhookService(storage *storage.WebhookStorage) *WebhookService {
+	allowPrivate := AllowPrivateWebhooks()
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	if !allowPrivate {
+		// Через прокси проверка адреса при подключении не работает.
+		transport.Proxy = nil
+		// Адрес проверяется при подключении, а не только при создании подписки:
+		// DNS-имя может позже начать указывать на внутренний адрес, а получатель - перенаправить запрос.
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+				return errPrivateTarget
+			}
+			return nil
+		}
+	}
+
+	return &WebhookService{
+		storage:      storage,
+		client:       &http.Client{Timeout: deliveryTimeout, Transport: transport},
+		wake:         make(chan struct{}, 1),
+		allowPrivate: allowPrivate,
+		busy:         make(map[int64]bool),
+	}
+}
+
+// AllowPrivateWebhooks разрешает подписки на адреса внутренней сети, loopback и link-local
+// при TODO_WEBHOOK_ALLOW_PRIVATE=true. По умолчанию они запрещены.
+func AllowPrivateWebhooks() bool {
+	allow, err := strconv.ParseBool(os.Getenv("TODO_WEBHOOK_ALLOW_PRIVATE"))
+	return err == nil && allow
+}
+
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// checkTarget отклоняет адрес подписки, если хотя бы один из адресов хоста внутренний.
+func (s *WebhookService) checkTarget(target *url.URL) error {
+	if s.allowPrivate {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(context.Background(), target.Hostname())
+	if err != nil {
+		return fmt.Errorf("не удалось найти адрес %s", target.Hostname())
+	}
+	for _, addr := range addrs {
+		if privateIP(addr.IP) {
+			return errPrivateTarget
+		}
+	}
+	return nil
+}
+
+// AddWebhook создаёт подписку. Если секрет не задан, он генерируется
+// и возвращается только в ответе на создание.
+func (s *WebhookService) AddWebhook(userID int64, webhook models.Webhook) (models.Webhook, error) {
+	target, err := url.Parse(webhook.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return models.Webhook{}, errors.New("адрес подписки должен быть http или https URL")
+	}
+	if err := s.checkTarget(target); err != nil {
+		return models.Webhook{}, err
+	}
+	for _, event := range webhook.Events {
+		if !slices.Contains(webhookEvents, event) {
+			return models.Webhook{}, fmt.Errorf("неизвестное событие %s", event)
+		}
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if webhook.Secret == "" {
+		webhook.Secret, err = jwt.RandomID()
+		if err != nil {
+			return models.Webhook{}, err
+		}
+	}
+
+	webhook.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	webhook.Id, err = s.storage.AddWebhook(userID, webhook)
+	return webhook, err
+}
+
+func (s *WebhookService) GetWebhooks(userID int64) ([]models.Webhook, error) {
+	return s.storage.GetWebhooks(userID)
+}
+
+func (s *WebhookService) DeleteWebhook(userID int64, id string) error {
+	return s.storage.DeleteWebhook(userID, id)
+}
+
+func (s *WebhookService) GetDeliveries(userID int64, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	return s.storage.GetDeliveries(userID, webhookID, limit)
+}
+
+// RetryDelivery отправляет недоставленное событие ещё раз, не дожидаясь следующей попытки.
+func (s *WebhookService) RetryDelivery(userID int64, id string) error {
+	err := s.storage.RetryDelivery(userID, id, time.Now().Unix())
+	if err == nil {
+		s.notify()
+	}
+	return err
+}
+
+// Publish ставит событие в очередь для всех подписок пользователя на него.
+// Ошибки только записываются в журнал: событие не должно отменять операцию с задачей.
+func (s *WebhookService) Publish(userID int64, event string, data any) {
+	webhooks, err := s.storage.Subscribers(userID, event)
+	if err != nil {
+		log.Printf("ошибка поиска подписок на %s: %v", event, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(map[string]any{
+		"event":       event,
+		"occurred_at": now.UTC().Format(time.RFC3339),
+		"data":        data,
+	})
+	if err != nil {
+		log.Printf("не удалось закодировать событие %s: %v", event, err)
+		return
+	}
+	for _, webhook := range webhooks {
+		err = s.storage.AddDelivery(models.WebhookDelivery{
+			WebhookId:     webhook.Id,
+			Event:         event,
+			Payload:       payload,
+			NextAttemptAt: now.Unix(),
+			CreatedAt:     now.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			log.Printf("ошибка постановки события %s в очередь: %v", event, err)
+		}
+	}
+	s.notify()
+}
+
+// Run отправляет доставки из очереди. Запускается один раз в отдельной горутине.
+func (s *WebhookService) Run() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		jobs, err := s.storage.DueDeliveries(time.Now().Unix(), deliveryBatch, s.busyWebhooks())
+		if err != nil {
+			log.Printf("ошибка чтения очереди событий: %v", err)
+		}
+		s.dispatch(jobs)
+		if len(jobs) == deliveryBatch {
+			continue
+		}
+		select {
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch отправляет каждую доставку в своей горутине. DueDeliveries возвращает
+// не больше одной доставки на подписку, а пока она отправляется, подписка считается
+// занятой и следующие её доставки из очереди не выбираются.
+func (s *WebhookService) dispatch(jobs []storage.DeliveryJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range jobs {
+		s.busy[job.WebhookId] = true
+		go func(job storage.DeliveryJob) {
+			s.deliver(job)
+			s.mu.Lock()
+			delete(s.busy, job.WebhookId)
+			s.mu.Unlock()
+			s.notify()
+		}(job)
+	}
+}
+
+func (s *WebhookService) busyWebhooks() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	busy := make([]int64, 0, len(s.busy))
+	for webhookID := range s.busy {
+		busy = append(busy, webhookID)
+	}
+	return busy
+}
+
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver отправляет событие с подписью X-Webhook-Signature: HMAC-SHA256 тела
+// на секрете подписки. Успехом считается любой ответ 2xx.
+func (s *WebhookService) deliver(job storage.DeliveryJob) {
+	delivery := models.WebhookDelivery{Id: job.Id, Attempts: job.Attempts + 1, Status: storage.DeliveryPending}
+
+	code, err := s.post(job)
+	delivery.ResponseCode = code
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = storage.DeliveryDelivered
+		delivery.DeliveredAt = now.UTC().Format(time.RFC3339)
+	case delivery.Attempts >= maxDeliveryAttempts:
+		delivery.Status = storage.DeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = now.Add(retryBackoff << (delivery.Attempts - 1)).Unix()
+	}
+
+	if err := s.storage.SaveAttempt(delivery); err != nil {
+		log.Printf("ошибка записи доставки %d: %v", job.Id, err)
+	}
+}
+
+func (s *WebhookService) post(job storage.DeliveryJob) (int, error) {
+	body := []byte(job.Payload)
+	req, err := http.NewRequest(http.MethodPost, job.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	mac := hmac.New(sha256.New, []byte(job.Secret))
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", job.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(job.Id, 10))
+	req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("получен ответ %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
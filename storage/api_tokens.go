@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type APITokenStorage struct {
+	db *sqlx.DB
+}
+
+func NewAPITokenStorage(db *sqlx.DB) *APITokenStorage {
+	return &APITokenStorage{db: db}
+}
+
+func (s *APITokenStorage) AddToken(userID int64, token models.APIToken, tokenHash string) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO api_tokens (user_id, name, token_hash, scope, created_at) VALUES (?, ?, ?, ?, ?)`,
+		userID, token.Name, tokenHash, token.Scope, token.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *APITokenStorage) GetTokens(userID int64) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := s.db.Select(&tokens,
+		`SELECT id, name, scope, created_at, last_used_at
+		FROM api_tokens WHERE user_id = ? AND revoked = 0 ORDER BY id`,
+		userID,
+	)
+	return tokens, err
+}
+
+// GetTokenOwner ищет действующий токен по хешу и возвращает владельца и область доступа.
+func (s *APITokenStorage) GetTokenOwner(tokenHash string) (int64, int64, string, error) {
+	var row struct {
+		Id     int64  `db:"id"`
+		UserId int64  `db:"user_id"`
+		Scope  string `db:"scope"`
+	}
+	err := s.db.Get(&row,
+		`SELECT id, user_id, scope FROM api_tokens WHERE token_hash = ? AND revoked = 0`,
+		tokenHash,
+	)
+	if err == sql.ErrNoRows {
+		return 0, 0, "", errors.New("токен не найден")
+	}
+	return row.Id, row.UserId, row.Scope, err
+}
+
+func (s *APITokenStorage) TouchToken(id int64, usedAt string) error {
+	_, err := s.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	return err
+}
+
+func (s *APITokenStorage) RevokeToken(userID int64, id string) error {
+	res, err := s.db.Exec(
+		`UPDATE api_tokens SET revoked = 1 WHERE id = ? AND user_id = ? AND revoked = 0`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("токен не найден")
+	}
+	return nil
+}
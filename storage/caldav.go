@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type CalDAVStorage struct {
+	db *sqlx.DB
+}
+
+func NewCalDAVStorage(db *sqlx.DB) *CalDAVStorage {
+	return &CalDAVStorage{db: db}
+}
+
+// AddResource связывает имя ресурса с задачей. Имя, оставшееся от задачи
+// в корзине, переходит к новой задаче.
+func (s *CalDAVStorage) AddResource(userID int64, resource models.CalDAVResource) error {
+	_, err := s.db.Exec(`INSERT INTO caldav_resources (user_id, name, uid, task_id) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, name) DO UPDATE SET uid = excluded.uid, task_id = excluded.task_id`,
+		userID, resource.Name, resource.UID, resource.TaskId)
+	return err
+}
+
+func (s *CalDAVStorage) DeleteResource(userID int64, name string) error {
+	_, err := s.db.Exec(`DELETE FROM caldav_resources WHERE user_id = ? AND name = ?`, userID, name)
+	return err
+}
+
+// GetResource ищет ресурс по имени; ok=false, если клиент не создавал ресурс с таким именем.
+func (s *CalDAVStorage) GetResource(userID int64, name string) (resource models.CalDAVResource, ok bool, err error) {
+	err = s.db.Get(&resource,
+		`SELECT name, uid, task_id FROM caldav_resources WHERE user_id = ? AND name = ?`,
+		userID, name,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return resource, false, nil
+	}
+	return resource, err == nil, err
+}
+
+// GetResources возвращает ресурсы пользователя по идентификаторам задач.
+func (s *CalDAVStorage) GetResources(userID int64) (map[string]models.CalDAVResource, error) {
+	var resources []models.CalDAVResource
+	err := s.db.Select(&resources,
+		`SELECT name, uid, task_id FROM caldav_resources WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	byTask := make(map[string]models.CalDAVResource, len(resources))
+	for _, resource := range resources {
+		byTask[resource.TaskId] = resource
+	}
+	return byTask, nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const checklistColumns = `id, task_id, title, position, done`
+
+// ownedItem ограничивает пункты чек-листа задачами пользователя вне корзины.
+const ownedItem = `task_id IN (SELECT id FROM scheduler WHERE user_id = ? AND deleted_at = '')`
+
+type ChecklistStorage struct {
+	db *sqlx.DB
+}
+
+func NewChecklistStorage(db *sqlx.DB) *ChecklistStorage {
+	return &ChecklistStorage{db: db}
+}
+
+// AddItem добавляет пункт в конец чек-листа задачи.
+func (s *ChecklistStorage) AddItem(userID int64, item models.ChecklistItem) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO checklist_items (task_id, title, position)
+		SELECT id, ?, COALESCE((SELECT MAX(position) FROM checklist_items WHERE task_id = scheduler.id), 0) + 1
+		FROM scheduler WHERE id = ? AND user_id = ? AND deleted_at = ''`,
+		item.Title, item.TaskId, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, errors.New("задача не найдена")
+	}
+	return result.LastInsertId()
+}
+
+func (s *ChecklistStorage) GetItems(userID int64, taskID string) ([]models.ChecklistItem, error) {
+	var exists bool
+	err := s.db.Get(&exists,
+		`SELECT EXISTS (SELECT 1 FROM scheduler WHERE id = ? AND user_id = ? AND deleted_at = '')`,
+		taskID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("задача не найдена")
+	}
+	return checklist(s.db, taskID)
+}
+
+func (s *ChecklistStorage) CountItems(userID int64, taskID string) (int, error) {
+	var count int
+	err := s.db.Get(&count,
+		`SELECT COUNT(*) FROM checklist_items WHERE task_id = ? AND `+ownedItem,
+		taskID, userID,
+	)
+	return count, err
+}
+
+func (s *ChecklistStorage) EditItem(userID int64, item models.ChecklistItem) error {
+	result, err := s.db.Exec(
+		`UPDATE checklist_items SET title = ? WHERE id = ? AND `+ownedItem,
+		item.Title, item.Id, userID,
+	)
+	return itemAffected(result, err)
+}
+
+func (s *ChecklistStorage) SetDone(userID int64, id string, done bool) error {
+	result, err := s.db.Exec(
+		`UPDATE checklist_items SET done = ? WHERE id = ? AND `+ownedItem,
+		done, id, userID,
+	)
+	return itemAffected(result, err)
+}
+
+func (s *ChecklistStorage) DeleteItem(userID int64, id string) error {
+	result, err := s.db.Exec(`DELETE FROM checklist_items WHERE id = ? AND `+ownedItem, id, userID)
+	return itemAffected(result, err)
+}
+
+// ItemTaskID возвращает id задачи, которой принадлежит пункт чек-листа.
+func (s *ChecklistStorage) ItemTaskID(userID int64, id string) (string, error) {
+	var taskID string
+	err := s.db.Get(&taskID, `SELECT task_id FROM checklist_items WHERE id = ? AND `+ownedItem, id, userID)
+	if err == sql.ErrNoRows {
+		return "", errors.New("пункт чек-листа не найден")
+	}
+	return taskID, err
+}
+
+// Reorder задаёт новый порядок пунктов. ids должен содержать все пункты чек-листа.
+func (s *ChecklistStorage) Reorder(userID int64, order models.ChecklistOrder) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.Get(&count, `SELECT COUNT(*) FROM checklist_items WHERE task_id = ? AND `+ownedItem, order.TaskId, userID)
+	if err != nil {
+		return err
+	}
+	if count != len(order.Ids) {
+		return fmt.Errorf("порядок должен содержать все пункты чек-листа: %d", count)
+	}
+
+	for i, id := range order.Ids {
+		result, err := tx.Exec(
+			`UPDATE checklist_items SET position = ? WHERE id = ? AND task_id = ? AND `+ownedItem,
+			i+1, id, order.TaskId, userID,
+		)
+		if err := itemAffected(result, err); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func checklist(q sqlx.Queryer, taskID string) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	err := sqlx.Select(q, &items,
+		`SELECT `+checklistColumns+` FROM checklist_items WHERE task_id = ? ORDER BY position, id`,
+		taskID,
+	)
+	return items, err
+}
+
+// restoreChecklist возвращает пунктам отметки из снимка и заново
+// добавляет пункты, удалённые вместе с задачей.
+func restoreChecklist(tx *sqlx.Tx, items []models.ChecklistItem) error {
+	for _, item := range items {
+		_, err := tx.Exec(
+			`INSERT INTO checklist_items (id, task_id, title, position, done) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET done = excluded.done`,
+			item.Id, item.TaskId, item.Title, item.Position, item.Done,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func itemAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("пункт чек-листа не найден")
+	}
+	return nil
+}
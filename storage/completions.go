@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const completionColumns = `id, task_id, date, title, comment, repeat, repeat_until, repeat_count,
+	time, timezone, priority, project_id, tags, checklist, completed_on, completed_at`
+
+// completionRow хранит теги снимка задачи одной строкой, а чек-лист - в JSON.
+type completionRow struct {
+	models.Completion
+	Tags      string `db:"tags"`
+	Checklist string `db:"checklist"`
+}
+
+func (row completionRow) completion() (models.Completion, error) {
+	completion := row.Completion
+	completion.Tags = splitTags(row.Tags)
+	if row.Checklist != "" {
+		err := json.Unmarshal([]byte(row.Checklist), &completion.Checklist)
+		if err != nil {
+			return completion, fmt.Errorf("checklist decode error: %w", err)
+		}
+	}
+	return completion, nil
+}
+
+type CompletionStorage struct {
+	db *sqlx.DB
+}
+
+func NewCompletionStorage(db *sqlx.DB) *CompletionStorage {
+	return &CompletionStorage{db: db}
+}
+
+// CompleteTask записывает выполнение задачи и в той же транзакции
+// переносит задачу на next или удаляет её, если next равен nil.
+// При переносе отметки в чек-листе снимаются для следующего повторения.
+func (s *CompletionStorage) CompleteTask(userID int64, completion models.Completion, next *models.Task) (int64, error) {
+	var checklist []byte
+	if len(completion.Checklist) > 0 {
+		var err error
+		checklist, err = json.Marshal(completion.Checklist)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO completions (user_id, task_id, date, title, comment, repeat, repeat_until, repeat_count,
+			time, timezone, priority, project_id, tags, checklist, completed_on, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, completion.TaskId, completion.Date, completion.Title, completion.Comment, completion.Repeat,
+		completion.RepeatUntil, completion.RepeatCount, completion.Time, completion.Timezone, completion.Priority,
+		completion.ProjectId,
+		joinTags(completion.Tags), string(checklist), completion.CompletedOn, completion.CompletedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if next == nil {
+		result, err = tx.Exec(`DELETE FROM scheduler WHERE id = ? AND user_id = ? AND deleted_at = ''`,
+			completion.TaskId, userID)
+	} else {
+		result, err = tx.Exec(`UPDATE scheduler SET date = ?, repeat_count = ? WHERE id = ? AND user_id = ? AND deleted_at = ''`,
+			next.Date, next.RepeatCount, completion.TaskId, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, errors.New("задача не найдена")
+	}
+
+	if next != nil {
+		_, err = tx.Exec(`UPDATE checklist_items SET done = 0 WHERE task_id = ?`, completion.TaskId)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+func (s *CompletionStorage) GetCompletions(userID int64, query models.CompletionQuery) ([]models.Completion, error) {
+	where := []string{"user_id = ?"}
+	args := []any{userID}
+	if query.TaskId != "" {
+		where = append(where, "task_id = ?")
+		args = append(args, query.TaskId)
+	}
+	if query.From != "" {
+		where = append(where, "completed_on >= ?")
+		args = append(args, query.From)
+	}
+	if query.To != "" {
+		where = append(where, "completed_on <= ?")
+		args = append(args, query.To)
+	}
+
+	var rows []completionRow
+	err := s.db.Select(&rows,
+		`SELECT `+completionColumns+` FROM completions
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC LIMIT ?`,
+		append(args, query.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	completions := make([]models.Completion, len(rows))
+	for i, row := range rows {
+		completions[i], err = row.completion()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return completions, nil
+}
+
+// UndoCompletion отменяет последнее выполнение задачи: возвращает задаче
+// прежнюю дату, число повторений и отметки чек-листа или восстанавливает удалённую задачу.
+// Задача из корзины при этом возвращается в список.
+func (s *CompletionStorage) UndoCompletion(userID int64, taskID string) (models.Completion, error) {
+	var row completionRow
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return row.Completion, err
+	}
+	defer tx.Rollback()
+
+	err = tx.Get(&row,
+		`SELECT `+completionColumns+` FROM completions
+		WHERE user_id = ? AND task_id = ? ORDER BY id DESC LIMIT 1`,
+		userID, taskID,
+	)
+	if err == sql.ErrNoRows {
+		return row.Completion, errors.New("у задачи нет выполнений")
+	}
+	if err != nil {
+		return row.Completion, err
+	}
+	completion, err := row.completion()
+	if err != nil {
+		return completion, err
+	}
+
+	result, err := tx.Exec(`UPDATE scheduler SET date = ?, repeat_count = ?, deleted_at = '' WHERE id = ? AND user_id = ?`,
+		completion.Date, completion.RepeatCount, completion.TaskId, userID)
+	if err != nil {
+		return completion, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return completion, err
+	}
+	if rowsAffected == 0 {
+		_, err = tx.Exec(
+			`INSERT INTO scheduler (id, date, title, comment, repeat, repeat_until, repeat_count, time, timezone,
+				priority, project_id, user_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(
+				(SELECT id FROM projects WHERE id = ? AND user_id = ?),
+				(SELECT id FROM projects WHERE user_id = ? AND is_default = 1), 0), ?)`,
+			completion.TaskId, completion.Date, completion.Title, completion.Comment, completion.Repeat,
+			completion.RepeatUntil, completion.RepeatCount, completion.Time, completion.Timezone,
+			completion.Priority, completion.ProjectId, userID, userID, userID,
+		)
+		if err != nil {
+			return completion, err
+		}
+		err = setTaskTags(tx, userID, completion.TaskId, completion.Tags)
+		if err != nil {
+			return completion, err
+		}
+	}
+	err = restoreChecklist(tx, completion.Checklist)
+	if err != nil {
+		return completion, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM completions WHERE id = ?`, completion.Id)
+	if err != nil {
+		return completion, err
+	}
+	return completion, tx.Commit()
+}
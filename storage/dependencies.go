@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// openPrerequisite - предварительная задача d.depends_on_id ещё не выполнена:
+// она есть в списке и не выполнялась с момента появления связи.
+const openPrerequisite = `prerequisite.deleted_at = ''
+	AND NOT EXISTS (SELECT 1 FROM completions
+		WHERE completions.task_id = d.depends_on_id AND completions.id > d.since_completion)`
+
+type DependencyStorage struct {
+	db *sqlx.DB
+}
+
+func NewDependencyStorage(db *sqlx.DB) *DependencyStorage {
+	return &DependencyStorage{db: db}
+}
+
+// AddDependency связывает две задачи пользователя. Повторная связь не меняет
+// момент, с которого учитывается выполнение предварительной задачи.
+func (s *DependencyStorage) AddDependency(userID int64, dependency models.Dependency) error {
+	_, err := s.db.Exec(
+		`INSERT OR IGNORE INTO task_dependencies (task_id, depends_on_id, since_completion)
+		SELECT task.id, prerequisite.id, (SELECT COALESCE(MAX(id), 0) FROM completions)
+		FROM scheduler AS task, scheduler AS prerequisite
+		WHERE task.id = ? AND task.user_id = ? AND task.deleted_at = ''
+			AND prerequisite.id = ? AND prerequisite.user_id = ? AND prerequisite.deleted_at = ''`,
+		dependency.TaskId, userID, dependency.DependsOn, userID,
+	)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	err = s.db.Get(&exists,
+		`SELECT EXISTS (SELECT 1 FROM task_dependencies WHERE task_id = ? AND depends_on_id = ?)`,
+		dependency.TaskId, dependency.DependsOn,
+	)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("задача не найдена")
+	}
+	return nil
+}
+
+func (s *DependencyStorage) DeleteDependency(userID int64, dependency models.Dependency) error {
+	res, err := s.db.Exec(
+		`DELETE FROM task_dependencies WHERE task_id = ? AND depends_on_id = ?
+		AND task_id IN (SELECT id FROM scheduler WHERE user_id = ?)`,
+		dependency.TaskId, dependency.DependsOn, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("зависимость не найдена")
+	}
+	return nil
+}
+
+// GetDependencies возвращает все связи между задачами пользователя.
+func (s *DependencyStorage) GetDependencies(userID int64) ([]models.Dependency, error) {
+	var dependencies []models.Dependency
+	err := s.db.Select(&dependencies,
+		`SELECT d.task_id, d.depends_on_id FROM task_dependencies AS d
+		JOIN scheduler AS task ON task.id = d.task_id
+		JOIN scheduler AS prerequisite ON prerequisite.id = d.depends_on_id
+		WHERE task.user_id = ? AND prerequisite.user_id = ?`,
+		userID, userID,
+	)
+	return dependencies, err
+}
+
+// blockedBy возвращает невыполненные задачи, от которых зависит задача taskID.
+func blockedBy(q sqlx.Queryer, taskID string) ([]models.TaskRef, error) {
+	var refs []models.TaskRef
+	err := sqlx.Select(q, &refs,
+		`SELECT prerequisite.id, prerequisite.title FROM task_dependencies AS d
+		JOIN scheduler AS prerequisite ON prerequisite.id = d.depends_on_id
+		WHERE d.task_id = ? AND `+openPrerequisite+`
+		ORDER BY prerequisite.date, prerequisite.id`,
+		taskID,
+	)
+	return refs, err
+}
+
+// blocking возвращает задачи, которые ждут выполнения задачи taskID.
+func blocking(q sqlx.Queryer, taskID string) ([]models.TaskRef, error) {
+	var refs []models.TaskRef
+	err := sqlx.Select(q, &refs,
+		`SELECT task.id, task.title FROM task_dependencies AS d
+		JOIN scheduler AS task ON task.id = d.task_id
+		JOIN scheduler AS prerequisite ON prerequisite.id = d.depends_on_id
+		WHERE d.depends_on_id = ? AND task.deleted_at = '' AND `+openPrerequisite+`
+		ORDER BY task.date, task.id`,
+		taskID,
+	)
+	return refs, err
+}
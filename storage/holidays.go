@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type HolidayStorage struct {
+	db *sqlx.DB
+}
+
+func NewHolidayStorage(db *sqlx.DB) *HolidayStorage {
+	return &HolidayStorage{db: db}
+}
+
+func (s *HolidayStorage) AddHoliday(holiday models.Holiday) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO holidays (date, title) VALUES (?, ?)`,
+		holiday.Date, holiday.Title,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// AddHolidayIfMissing не добавляет праздник, если дата уже есть в календаре.
+func (s *HolidayStorage) AddHolidayIfMissing(holiday models.Holiday) (bool, error) {
+	result, err := s.db.Exec(
+		`INSERT OR IGNORE INTO holidays (date, title) VALUES (?, ?)`,
+		holiday.Date, holiday.Title,
+	)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+func (s *HolidayStorage) EditHoliday(holiday models.Holiday) error {
+	res, err := s.db.Exec(
+		`UPDATE holidays SET date = ?, title = ? WHERE id = ?`,
+		holiday.Date, holiday.Title, holiday.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("праздник не найден")
+	}
+	return nil
+}
+
+func (s *HolidayStorage) GetHoliday(id string) (models.Holiday, error) {
+	var holiday models.Holiday
+	err := s.db.Get(&holiday, `SELECT id, date, title FROM holidays WHERE id = ?`, id)
+	if err == sql.ErrNoRows {
+		return holiday, errors.New("праздник не найден")
+	}
+	return holiday, err
+}
+
+func (s *HolidayStorage) GetHolidays() ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	err := s.db.Select(&holidays, `SELECT id, date, title FROM holidays ORDER BY date`)
+	return holidays, err
+}
+
+func (s *HolidayStorage) DeleteHoliday(id string) error {
+	res, err := s.db.Exec(`DELETE FROM holidays WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("праздник не найден")
+	}
+	return nil
+}
@@ -2,60 +2,44 @@ package storage
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	_ "modernc.org/sqlite"
 )
 
+// CreateDB открывает БД из TODO_DBFILE и применяет недостающие миграции.
 func CreateDB() (*sqlx.DB, error) {
-	dbFile := os.Getenv("TODO_DBFILE")
-	_, err := os.Stat(dbFile)
-	var install bool
+	db, err := OpenDB()
 	if err != nil {
-		install = true
-	}
-	if install {
-		err = CreateTable(dbFile)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
 
-	db, err := OpenSql(dbFile)
+	applied, err := Migrate(db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	for _, migration := range applied {
+		log.Printf("применена миграция %04d_%s", migration.Version, migration.Name)
+	}
 	return db, nil
 }
 
-func CreateTable(path string) error {
-	db, err := OpenSql(path)
-
-	if err != nil {
-		return err
-	}
-
-	createQuery := `
-	CREATE TABLE IF NOT EXISTS scheduler (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		date CHAR(8) NOT NULL DEFAULT "19700101",
-		title VARCHAR(128) NOT NULL DEFAULT "",
-		comment VARCHAR(256) NOT NULL DEFAULT "",
-		repeat VARCHAR(128) NOT NULL DEFAULT ""
-	);
-	CREATE INDEX date_scheduler on scheduler (date);
-	`
-
-	_, err = db.Exec(createQuery)
-	if err != nil {
-		return fmt.Errorf("table create error: %w", err)
-	}
-	return db.Close()
+func OpenDB() (*sqlx.DB, error) {
+	return OpenSql(os.Getenv("TODO_DBFILE"))
 }
 
+// OpenSql открывает БД с ожиданием блокировки: запросы не падают с ошибкой
+// database is locked, пока фоновые задачи (очистка корзины, доставка событий) пишут в БД.
 func OpenSql(path string) (*sqlx.DB, error) {
-	db, err := sqlx.Open("sqlite", path)
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	db, err := sqlx.Open("sqlite", path+separator+"_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("db open error: %w", err)
 	}
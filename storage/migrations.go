@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var addColumnStatement = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(\w+)[^;]*;`)
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type AppliedMigration struct {
+	Version   int    `db:"version"`
+	Name      string `db:"name"`
+	AppliedAt string `db:"applied_at"`
+}
+
+// Migrations возвращает встроенные миграции по возрастанию версии.
+// Файлы называются NNNN_name.sql, версии идут подряд начиная с 1.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, file := range files {
+		base := strings.TrimSuffix(strings.TrimPrefix(file, "migrations/"), ".sql")
+		number, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration name error: %s", file)
+		}
+		version, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, fmt.Errorf("migration version error: %s", file)
+		}
+		body, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration sequence error: expected %04d, got %04d", i+1, migration.Version)
+		}
+	}
+	return migrations, nil
+}
+
+func SchemaVersion(db *sqlx.DB) (int, error) {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(128) NOT NULL DEFAULT "",
+		applied_at VARCHAR(32) NOT NULL DEFAULT ""
+	);
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("schema_version create error: %w", err)
+	}
+
+	var version int
+	err = db.Get(&version, `SELECT COALESCE(MAX(version), 0) FROM schema_version`)
+	if err != nil {
+		return 0, fmt.Errorf("schema_version read error: %w", err)
+	}
+	return version, nil
+}
+
+func AppliedMigrations(db *sqlx.DB) ([]AppliedMigration, error) {
+	if _, err := SchemaVersion(db); err != nil {
+		return nil, err
+	}
+	var applied []AppliedMigration
+	err := db.Select(&applied, `SELECT version, name, applied_at FROM schema_version ORDER BY version`)
+	return applied, err
+}
+
+// PendingMigrations возвращает ещё не применённые миграции и отказывает,
+// если схема БД новее, чем известно этой сборке.
+func PendingMigrations(db *sqlx.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version > len(migrations) {
+		return nil, fmt.Errorf("schema version %d is newer than supported %d", version, len(migrations))
+	}
+	return migrations[version:], nil
+}
+
+func Migrate(db *sqlx.DB) ([]Migration, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err = applyMigration(db, migration)
+		if err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+func applyMigration(db *sqlx.DB, migration Migration) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, err := skipExistingColumns(tx, migration.SQL)
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s error: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.Exec(query)
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s error: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.Exec(
+		`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("schema_version update error: %w", err)
+	}
+	return tx.Commit()
+}
+
+// skipExistingColumns убирает из миграции добавление уже существующих столбцов.
+// В БД, созданных до появления schema_version, эти столбцы добавлялись при запуске
+// сервера, и повторный ALTER TABLE ADD COLUMN завершился бы ошибкой duplicate column.
+func skipExistingColumns(tx *sqlx.Tx, query string) (string, error) {
+	var err error
+	query = addColumnStatement.ReplaceAllStringFunc(query, func(statement string) string {
+		if err != nil {
+			return statement
+		}
+		match := addColumnStatement.FindStringSubmatch(statement)
+		var exists bool
+		err = tx.Get(&exists,
+			`SELECT count(*) > 0 FROM pragma_table_info(?) WHERE name = ?`, match[1], match[2])
+		if exists {
+			return ""
+		}
+		return statement
+	})
+	return query, err
+}
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS holidays (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	date CHAR(8) NOT NULL UNIQUE,
+	title VARCHAR(128) NOT NULL DEFAULT ""
+);
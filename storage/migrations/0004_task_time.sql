@@ -0,0 +1,2 @@
+ALTER TABLE scheduler ADD COLUMN time CHAR(5) NOT NULL DEFAULT "";
+ALTER TABLE scheduler ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT "";
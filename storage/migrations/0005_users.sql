@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	login VARCHAR(64) NOT NULL UNIQUE,
+	password_hash VARCHAR(128) NOT NULL
+);
+ALTER TABLE scheduler ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS user_scheduler ON scheduler (user_id, date);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS sessions (
+	id CHAR(32) PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	refresh_hash CHAR(64) NOT NULL,
+	expires_at INTEGER NOT NULL,
+	revoked INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	scope VARCHAR(16) NOT NULL DEFAULT "write",
+	created_at VARCHAR(32) NOT NULL DEFAULT "",
+	last_used_at VARCHAR(32) NOT NULL DEFAULT "",
+	revoked INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,22 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS scheduler_fts USING fts5(
+	title,
+	comment,
+	content = 'scheduler',
+	content_rowid = 'id',
+	tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS scheduler_fts_insert AFTER INSERT ON scheduler BEGIN
+	INSERT INTO scheduler_fts (rowid, title, comment) VALUES (new.id, new.title, new.comment);
+END;
+
+CREATE TRIGGER IF NOT EXISTS scheduler_fts_delete AFTER DELETE ON scheduler BEGIN
+	INSERT INTO scheduler_fts (scheduler_fts, rowid, title, comment) VALUES ('delete', old.id, old.title, old.comment);
+END;
+
+CREATE TRIGGER IF NOT EXISTS scheduler_fts_update AFTER UPDATE OF title, comment ON scheduler BEGIN
+	INSERT INTO scheduler_fts (scheduler_fts, rowid, title, comment) VALUES ('delete', old.id, old.title, old.comment);
+	INSERT INTO scheduler_fts (rowid, title, comment) VALUES (new.id, new.title, new.comment);
+END;
+
+INSERT INTO scheduler_fts (scheduler_fts) VALUES ('rebuild');
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS completions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL DEFAULT 0,
+	task_id INTEGER NOT NULL,
+	date CHAR(8) NOT NULL,
+	title VARCHAR(128) NOT NULL DEFAULT "",
+	comment VARCHAR(256) NOT NULL DEFAULT "",
+	repeat VARCHAR(128) NOT NULL DEFAULT "",
+	repeat_until CHAR(8) NOT NULL DEFAULT "",
+	repeat_count INTEGER NOT NULL DEFAULT 0,
+	time CHAR(5) NOT NULL DEFAULT "",
+	timezone VARCHAR(64) NOT NULL DEFAULT "",
+	completed_on CHAR(8) NOT NULL,
+	completed_at VARCHAR(32) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS user_completions ON completions (user_id, completed_on);
+CREATE INDEX IF NOT EXISTS task_completions ON completions (task_id);
@@ -0,0 +1,2 @@
+ALTER TABLE scheduler ADD COLUMN deleted_at VARCHAR(32) NOT NULL DEFAULT "";
+CREATE INDEX IF NOT EXISTS trash_scheduler ON scheduler (user_id, deleted_at);
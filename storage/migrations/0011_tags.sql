@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL DEFAULT 0,
+	name VARCHAR(32) NOT NULL,
+	UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_tags (
+	task_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (task_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS tag_task_tags ON task_tags (tag_id);
+
+CREATE TRIGGER IF NOT EXISTS scheduler_tags_delete AFTER DELETE ON scheduler BEGIN
+	DELETE FROM task_tags WHERE task_id = old.id;
+END;
+
+ALTER TABLE completions ADD COLUMN tags VARCHAR(1024) NOT NULL DEFAULT "";
@@ -0,0 +1,3 @@
+ALTER TABLE scheduler ADD COLUMN priority INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE completions ADD COLUMN priority INTEGER NOT NULL DEFAULT 2;
+CREATE INDEX IF NOT EXISTS priority_scheduler ON scheduler (user_id, date, priority);
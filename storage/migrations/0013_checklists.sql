@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS checklist_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id INTEGER NOT NULL,
+	title VARCHAR(128) NOT NULL DEFAULT "",
+	position INTEGER NOT NULL DEFAULT 0,
+	done INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS task_checklist_items ON checklist_items (task_id, position);
+
+CREATE TRIGGER IF NOT EXISTS scheduler_checklist_delete AFTER DELETE ON scheduler BEGIN
+	DELETE FROM checklist_items WHERE task_id = old.id;
+END;
+
+ALTER TABLE completions ADD COLUMN checklist TEXT NOT NULL DEFAULT "";
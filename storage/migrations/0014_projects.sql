@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL DEFAULT 0,
+	name VARCHAR(64) NOT NULL,
+	color CHAR(7) NOT NULL DEFAULT "",
+	archived INTEGER NOT NULL DEFAULT 0,
+	is_default INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (user_id, name)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS default_projects ON projects (user_id) WHERE is_default = 1;
+
+INSERT INTO projects (user_id, name, is_default)
+SELECT DISTINCT user_id, "Входящие", 1 FROM scheduler;
+
+ALTER TABLE scheduler ADD COLUMN project_id INTEGER NOT NULL DEFAULT 0;
+UPDATE scheduler SET project_id = (
+	SELECT id FROM projects WHERE projects.user_id = scheduler.user_id AND is_default = 1
+);
+CREATE INDEX IF NOT EXISTS project_scheduler ON scheduler (user_id, project_id);
+
+ALTER TABLE completions ADD COLUMN project_id INTEGER NOT NULL DEFAULT 0;
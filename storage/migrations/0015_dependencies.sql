@@ -0,0 +1,9 @@
+-- Связи не удаляются вместе с задачей: отмена выполнения возвращает задачу
+-- с тем же id, и её зависимости снова действуют.
+CREATE TABLE IF NOT EXISTS task_dependencies (
+	task_id INTEGER NOT NULL,
+	depends_on_id INTEGER NOT NULL,
+	since_completion INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (task_id, depends_on_id)
+);
+CREATE INDEX IF NOT EXISTS depends_on_task_dependencies ON task_dependencies (depends_on_id);
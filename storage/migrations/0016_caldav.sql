@@ -0,0 +1,9 @@
+-- Имена ресурсов, которые задали клиенты CalDAV при создании задач.
+-- Задачи без записи здесь доступны по имени <id>.ics.
+CREATE TABLE IF NOT EXISTS caldav_resources (
+	user_id INTEGER NOT NULL,
+	name VARCHAR(256) NOT NULL,
+	uid VARCHAR(256) NOT NULL,
+	task_id INTEGER NOT NULL UNIQUE,
+	PRIMARY KEY (user_id, name)
+);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	url VARCHAR(2048) NOT NULL,
+	secret VARCHAR(128) NOT NULL,
+	events VARCHAR(256) NOT NULL DEFAULT '',
+	created_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS user_webhooks ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL,
+	event VARCHAR(32) NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	response_code INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	next_attempt_at INTEGER NOT NULL DEFAULT 0,
+	created_at VARCHAR(32) NOT NULL DEFAULT '',
+	delivered_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS due_webhook_deliveries ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_webhook_deliveries ON webhook_deliveries (webhook_id);
+
+CREATE TRIGGER IF NOT EXISTS webhooks_delete AFTER DELETE ON webhooks BEGIN
+	DELETE FROM webhook_deliveries WHERE webhook_id = old.id;
+END;
@@ -0,0 +1,6 @@
+-- Имя ресурса CalDAV освобождается вместе с задачей, иначе клиент не сможет создать ресурс с тем же именем.
+DELETE FROM caldav_resources WHERE task_id NOT IN (SELECT id FROM scheduler);
+
+CREATE TRIGGER IF NOT EXISTS scheduler_caldav_delete AFTER DELETE ON scheduler BEGIN
+	DELETE FROM caldav_resources WHERE task_id = old.id;
+END;
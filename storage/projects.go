@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	projectColumns     = `id, name, color, archived, is_default`
+	DefaultProjectName = "Входящие"
+)
+
+var ErrProjectNotFound = errors.New("проект не найден")
+
+type ProjectStorage struct {
+	db *sqlx.DB
+}
+
+func NewProjectStorage(db *sqlx.DB) *ProjectStorage {
+	return &ProjectStorage{db: db}
+}
+
+func (s *ProjectStorage) AddProject(userID int64, project models.Project) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO projects (user_id, name, color, archived) VALUES (?, ?, ?, ?)`,
+		userID, project.Name, project.Color, project.Archived,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// DefaultProject возвращает проект по умолчанию, создавая его при первом обращении.
+func (s *ProjectStorage) DefaultProject(userID int64) (models.Project, error) {
+	_, err := s.db.Exec(
+		`INSERT INTO projects (user_id, name, is_default)
+		SELECT ?, ?, 1 WHERE NOT EXISTS (SELECT 1 FROM projects WHERE user_id = ? AND is_default = 1)`,
+		userID, DefaultProjectName, userID,
+	)
+	if err != nil {
+		return models.Project{}, err
+	}
+
+	var project models.Project
+	err = s.db.Get(&project,
+		`SELECT `+projectColumns+` FROM projects WHERE user_id = ? AND is_default = 1`,
+		userID,
+	)
+	return project, err
+}
+
+func (s *ProjectStorage) GetProject(userID int64, id string) (models.Project, error) {
+	var project models.Project
+	err := s.db.Get(&project,
+		`SELECT `+projectColumns+` FROM projects WHERE id = ? AND user_id = ?`,
+		id, userID,
+	)
+	if err == sql.ErrNoRows {
+		return project, ErrProjectNotFound
+	}
+	return project, err
+}
+
+func (s *ProjectStorage) GetProjects(userID int64, archived bool) ([]models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE user_id = ?`
+	if !archived {
+		query += ` AND archived = 0`
+	}
+	var projects []models.Project
+	err := s.db.Select(&projects, query+` ORDER BY is_default DESC, name`, userID)
+	return projects, err
+}
+
+func (s *ProjectStorage) EditProject(userID int64, project models.Project) error {
+	res, err := s.db.Exec(
+		`UPDATE projects SET name = ?, color = ?, archived = ? WHERE id = ? AND user_id = ?`,
+		project.Name, project.Color, project.Archived, project.Id, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("проект не найден")
+	}
+	return nil
+}
+
+// DeleteProject удаляет проект, перенося его задачи в проект defaultID.
+func (s *ProjectStorage) DeleteProject(userID int64, id string, defaultID string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE scheduler SET project_id = ? WHERE project_id = ? AND user_id = ?`, defaultID, id, userID)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM projects WHERE id = ? AND user_id = ? AND is_default = 0`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("проект не найден")
+	}
+	return tx.Commit()
+}
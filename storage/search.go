@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var ftsOperators = map[string]bool{"AND": true, "OR": true, "NOT": true}
+
+// Границы совпадения в snippet: управляющие символы вместо тегов, чтобы
+// текст задачи можно было экранировать до вставки <mark>.
+const (
+	snippetStart = "\x02"
+	snippetEnd   = "\x03"
+)
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetEnd, "</mark>")
+
+// markSnippet экранирует текст фрагмента для HTML и выделяет совпадения тегом <mark>.
+func markSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
+
+// ftsQuery переводит строку поиска в запрос FTS5: слова и фразы в кавычках
+// экранируются, "слово*" ищет по префиксу. С operators=true сохраняются
+// AND, OR, NOT и скобки, иначе все слова ищутся как обычный текст.
+func ftsQuery(search string, operators bool) string {
+	var terms []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		term := word.String()
+		word.Reset()
+		switch {
+		case operators && ftsOperators[term]:
+			terms = append(terms, term)
+		case strings.HasSuffix(term, "*") && len(term) > 1:
+			terms = append(terms, quoteFTS(strings.TrimRight(term, "*"))+"*")
+		default:
+			terms = append(terms, quoteFTS(term))
+		}
+	}
+
+	runes := []rune(search)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == '"':
+			flush()
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			phrase := string(runes[i+1 : end])
+			i = end
+			if strings.TrimSpace(phrase) == "" {
+				continue
+			}
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				terms = append(terms, quoteFTS(phrase)+"*")
+				i++
+				continue
+			}
+			terms = append(terms, quoteFTS(phrase))
+		case operators && (ch == '(' || ch == ')'):
+			flush()
+			terms = append(terms, string(ch))
+		case unicode.IsSpace(ch):
+			flush()
+		default:
+			word.WriteRune(ch)
+		}
+	}
+	flush()
+	return strings.Join(terms, " ")
+}
+
+func quoteFTS(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
+
+// isFTSSyntaxError отличает ошибку разбора запроса FTS5 от прочих ошибок БД
+// (блокировка, повреждённый индекс), которые нельзя скрывать повторным поиском.
+func isFTSSyntaxError(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_ERROR &&
+		strings.Contains(err.Error(), "fts5: syntax error")
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionStorage struct {
+	db *sqlx.DB
+}
+
+func NewSessionStorage(db *sqlx.DB) *SessionStorage {
+	return &SessionStorage{db: db}
+}
+
+func (s *SessionStorage) AddSession(session models.Session) error {
+	_, err := s.db.Exec(
+		`INSERT INTO sessions (id, user_id, refresh_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		session.Id, session.UserId, session.RefreshHash, session.ExpiresAt,
+	)
+	return err
+}
+
+func (s *SessionStorage) GetSession(id string) (models.Session, error) {
+	var session models.Session
+	err := s.db.Get(&session,
+		`SELECT id, user_id, refresh_hash, expires_at, revoked FROM sessions WHERE id = ?`,
+		id,
+	)
+	if err == sql.ErrNoRows {
+		return session, errors.New("сессия не найдена")
+	}
+	return session, err
+}
+
+// RotateRefresh заменяет хеш refresh-токена, только если предъявлен текущий.
+func (s *SessionStorage) RotateRefresh(id string, oldHash string, newHash string, expiresAt int64) (bool, error) {
+	res, err := s.db.Exec(
+		`UPDATE sessions SET refresh_hash = ?, expires_at = ?
+		WHERE id = ? AND refresh_hash = ? AND revoked = 0`,
+		newHash, expiresAt, id, oldHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+func (s *SessionStorage) RevokeSession(id string) error {
+	_, err := s.db.Exec(`UPDATE sessions SET revoked = 1 WHERE id = ?`, id)
+	return err
+}
+
+func (s *SessionStorage) DeleteExpired(now int64) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at < ?`, now)
+	return err
+}
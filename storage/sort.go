@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/models"
+)
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+// descending сообщает направление столбца: первый столбец следует
+// параметру order, у остальных направление фиксировано.
+func (k sortKey) descending(orderDesc bool, position int) bool {
+	if position == 0 {
+		return orderDesc
+	}
+	return k.desc
+}
+
+// taskSortKeys задаёт столбцы сортировки списка задач. Дополнительные
+// столбцы упорядочивают задачи внутри дня (срочные выше) и делают порядок
+// однозначным для постраничного вывода.
+var taskSortKeys = map[string][]sortKey{
+	"date":     {{column: "date"}, {column: "priority", desc: true}, {column: "time"}, {column: "id"}},
+	"priority": {{column: "priority"}, {column: "date"}, {column: "time"}, {column: "id"}},
+	"title":    {{column: "title"}, {column: "id"}},
+	"id":       {{column: "id"}},
+}
+
+func IsTaskSort(sort string) bool {
+	_, ok := taskSortKeys[sort]
+	return ok
+}
+
+// TaskSortValues возвращает значения ключа сортировки sort для задачи.
+func TaskSortValues(task models.Task, sort string) []string {
+	switch sort {
+	case "date":
+		return []string{task.Date, strconv.FormatInt(task.Priority.Rank(), 10), task.Time, task.Id}
+	case "priority":
+		return []string{strconv.FormatInt(task.Priority.Rank(), 10), task.Date, task.Time, task.Id}
+	case "title":
+		return []string{task.Title, task.Id}
+	default:
+		return []string{task.Id}
+	}
+}
+
+// keysetCondition строит условие "строка идёт после after" для сортировки по keys.
+func keysetCondition(keys []sortKey, orderDesc bool, after []string) (string, []any) {
+	var alternatives []string
+	var args []any
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].column+" = ?")
+			args = append(args, after[j])
+		}
+		op := ">"
+		if key.descending(orderDesc, i) {
+			op = "<"
+		}
+		parts = append(parts, key.column+" "+op+" ?")
+		args = append(args, after[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
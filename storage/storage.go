@@ -3,11 +3,16 @@ package storage
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Yandex-Practicum/final-project/models"
 	"github.com/jmoiron/sqlx"
 )
 
+const taskColumns = `id, date, title, comment, repeat, repeat_until, repeat_count, time, timezone, priority, project_id, deleted_at`
+
 type TaskStorage struct {
 	db *sqlx.DB
 }
@@ -16,20 +21,49 @@ func NewTaskStorage(db *sqlx.DB) *TaskStorage {
 	return &TaskStorage{db: db}
 }
 
-func (s *TaskStorage) AddTask(task models.Task) (int64, error) {
-	result, err := s.db.Exec(
-		`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, ?, ?)`,
-		task.Date, task.Title, task.Comment, task.Repeat,
+func (s *TaskStorage) AddTask(userID int64, task models.Task) (int64, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO scheduler (id, date, title, comment, repeat, repeat_until, repeat_count, time, timezone, priority,
+			project_id, user_id)
+		VALUES (NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		task.Id, task.Date, task.Title, task.Comment, task.Repeat, task.RepeatUntil, task.RepeatCount,
+		task.Time, task.Timezone, task.Priority, task.ProjectId, userID,
 	)
 	if err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	err = setTaskTags(tx, userID, id, task.Tags)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
 }
 
-func (s *TaskStorage) EditTask(task models.Task) error {
-	query := `UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ?`
-	res, err := s.db.Exec(query, task.Date, task.Title, task.Comment, task.Repeat, task.Id)
+// EditTask обновляет задачу. Приоритет, проект и теги заменяются, только если они переданы.
+func (s *TaskStorage) EditTask(userID int64, task models.Task) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ?,
+		repeat_until = ?, repeat_count = ?, time = ?, timezone = ?, priority = COALESCE(NULLIF(?, 0), priority),
+		project_id = COALESCE(NULLIF(?, ''), project_id)
+		WHERE id = ? AND user_id = ? AND deleted_at = ''`
+	res, err := tx.Exec(query, task.Date, task.Title, task.Comment, task.Repeat,
+		task.RepeatUntil, task.RepeatCount, task.Time, task.Timezone, task.Priority, task.ProjectId, task.Id, userID)
 	if err != nil {
 		return err
 	}
@@ -42,56 +76,243 @@ func (s *TaskStorage) EditTask(task models.Task) error {
 	if rowsAffected == 0 {
 		return errors.New("задача не найдена")
 	}
-	return nil
+
+	if task.Tags != nil {
+		err = setTaskTags(tx, userID, task.Id, task.Tags)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (s *TaskStorage) GetTask(id string) (models.Task, error) {
+func (s *TaskStorage) GetTask(userID int64, id string) (models.Task, error) {
 	var task models.Task
-	err := s.db.QueryRow(
-		`SELECT id, date, title, comment, repeat FROM scheduler WHERE id = ?`,
-		id,
-	).Scan(&task.Id, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+	err := s.db.Get(&task,
+		`SELECT `+taskColumns+` FROM scheduler WHERE id = ? AND user_id = ? AND deleted_at = ''`,
+		id, userID,
+	)
 
 	if err == sql.ErrNoRows {
 		return task, errors.New("задача не найдена")
 	}
+	if err != nil {
+		return task, err
+	}
+
+	tasks := []models.Task{task}
+	err = s.attachTags(tasks)
+	if err != nil {
+		return task, err
+	}
+	task = tasks[0]
+	task.Checklist, err = checklist(s.db, task.Id)
+	if err != nil {
+		return task, err
+	}
+	task.BlockedBy, err = blockedBy(s.db, task.Id)
+	if err != nil {
+		return task, err
+	}
+	task.Blocking, err = blocking(s.db, task.Id)
 	return task, err
 }
 
-func (s *TaskStorage) GetTasks(limit int) ([]models.Task, error) {
+func (s *TaskStorage) GetTasks(userID int64, query models.TaskQuery, after []string) ([]models.Task, error) {
+	keys, ok := taskSortKeys[query.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort: %s", query.Sort)
+	}
+	where := []string{"user_id = ?", "deleted_at = ''"}
+	args := []any{userID}
+	if query.ProjectId != "" {
+		where = append(where, "project_id = ?")
+		args = append(args, query.ProjectId)
+	} else {
+		where = append(where, "project_id NOT IN (SELECT id FROM projects WHERE user_id = ? AND archived = 1)")
+		args = append(args, userID)
+	}
+	if query.From != "" {
+		where = append(where, "date >= ?")
+		args = append(args, query.From)
+	}
+	if query.To != "" {
+		where = append(where, "date <= ?")
+		args = append(args, query.To)
+	}
+	if query.HasRepeat != nil {
+		if *query.HasRepeat {
+			where = append(where, "repeat != ''")
+		} else {
+			where = append(where, "repeat = ''")
+		}
+	}
+	if len(query.Priorities) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(query.Priorities)), ", ")
+		where = append(where, "priority IN ("+placeholders+")")
+		for _, priority := range query.Priorities {
+			args = append(args, priority)
+		}
+	}
+	if len(query.Tags) > 0 {
+		condition, conditionArgs := tagsCondition(userID, query.Tags, query.TagsMode)
+		where = append(where, condition)
+		args = append(args, conditionArgs...)
+	}
+	if after != nil {
+		if len(after) != len(keys) {
+			return nil, fmt.Errorf("sort key length error: expected %d, got %d", len(keys), len(after))
+		}
+		condition, conditionArgs := keysetCondition(keys, query.Order == "desc", after)
+		where = append(where, condition)
+		args = append(args, conditionArgs...)
+	}
+
+	orderBy := make([]string, len(keys))
+	for i, key := range keys {
+		orderBy[i] = key.column + " ASC"
+		if key.descending(query.Order == "desc", i) {
+			orderBy[i] = key.column + " DESC"
+		}
+	}
+
 	var tasks []models.Task
 	err := s.db.Select(&tasks,
-		`SELECT id, date, title, comment, repeat 
-		FROM scheduler ORDER BY date DESC LIMIT ?`,
-		limit,
+		`SELECT `+taskColumns+` FROM scheduler
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+strings.Join(orderBy, ", ")+` LIMIT ?`,
+		append(args, query.Limit)...,
 	)
-	return tasks, err
+	if err != nil {
+		return nil, err
+	}
+	return tasks, s.attachTags(tasks)
 }
 
-func (s *TaskStorage) SearchByDate(date string) ([]models.Task, error) {
+func (s *TaskStorage) SearchByDate(userID int64, date string, limit int) ([]models.Task, error) {
 	var tasks []models.Task
 	err := s.db.Select(&tasks,
-		`SELECT id, date, title, comment, repeat 
-		FROM scheduler WHERE date = ? ORDER BY date DESC`,
-		date,
+		`SELECT `+taskColumns+`
+		FROM scheduler WHERE user_id = ? AND date = ? AND deleted_at = '' ORDER BY priority DESC, time LIMIT ?`,
+		userID, date, limit,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, s.attachTags(tasks)
+}
+
+// SearchByText ищет задачи по полнотекстовому индексу scheduler_fts
+// и возвращает их по убыванию релевантности вместе с фрагментом совпадения.
+// Если запрос с операторами не разбирается FTS5, слова ищутся как обычный текст.
+func (s *TaskStorage) SearchByText(userID int64, text string, limit int) ([]models.Task, error) {
+	tasks, err := s.searchFTS(userID, ftsQuery(text, true), limit)
+	if isFTSSyntaxError(err) {
+		return s.searchFTS(userID, ftsQuery(text, false), limit)
+	}
 	return tasks, err
 }
 
-func (s *TaskStorage) SearchByText(text string) ([]models.Task, error) {
+func (s *TaskStorage) searchFTS(userID int64, query string, limit int) ([]models.Task, error) {
 	var tasks []models.Task
+	if query == "" {
+		return tasks, nil
+	}
 	err := s.db.Select(&tasks,
-		`SELECT id, date, title, comment, repeat 
-		FROM scheduler 
-		WHERE title LIKE ? OR comment LIKE ? 
-		ORDER BY date DESC LIMIT 100`,
-		"%"+text+"%", "%"+text+"%",
+		`SELECT `+taskColumns+`, found.snippet
+		FROM scheduler JOIN (
+			SELECT rowid, bm25(scheduler_fts, 2.0, 1.0) AS score,
+				snippet(scheduler_fts, -1, char(2), char(3), '…', 12) AS snippet
+			FROM scheduler_fts WHERE scheduler_fts MATCH ?
+		) AS found ON found.rowid = scheduler.id
+		WHERE user_id = ? AND deleted_at = ''
+		ORDER BY found.score, date DESC LIMIT ?`,
+		query, userID, limit,
 	)
-	return tasks, err
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].Snippet = markSnippet(tasks[i].Snippet)
+	}
+	return tasks, s.attachTags(tasks)
+}
+
+// DeleteTask переносит задачу в корзину.
+func (s *TaskStorage) DeleteTask(userID int64, id string, deletedAt string) error {
+	res, err := s.db.Exec(`UPDATE scheduler SET deleted_at = ? WHERE id = ? AND user_id = ? AND deleted_at = ''`,
+		deletedAt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("задача не найдена")
+	}
+	return nil
 }
 
-func (s *TaskStorage) DeleteTask(id string) error {
-	res, err := s.db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+// AllTasks возвращает все задачи пользователя вне корзины, например для экспорта.
+func (s *TaskStorage) AllTasks(userID int64) ([]models.Task, error) {
+	var tasks []models.Task
+	err := s.db.Select(&tasks,
+		`SELECT `+taskColumns+` FROM scheduler WHERE user_id = ? AND deleted_at = '' ORDER BY date, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, s.attachTags(tasks)
+}
+
+// IsTaskIdFree проверяет, что идентификатор не занят задачей, в том числе удалённой
+// и чужой, и не встречается в истории выполнения и зависимостях.
+func (s *TaskStorage) IsTaskIdFree(id string) (bool, error) {
+	if n, err := strconv.ParseInt(id, 10, 64); err != nil || n < 1 {
+		return false, nil
+	}
+	var free bool
+	err := s.db.Get(&free,
+		`SELECT NOT EXISTS (SELECT 1 FROM scheduler WHERE id = ?)
+			AND NOT EXISTS (SELECT 1 FROM completions WHERE task_id = ?)
+			AND NOT EXISTS (SELECT 1 FROM task_dependencies WHERE task_id = ? OR depends_on_id = ?)`,
+		id, id, id, id,
+	)
+	return free, err
+}
+
+// HasTask проверяет, есть ли у пользователя задача с таким заголовком на эту дату.
+func (s *TaskStorage) HasTask(userID int64, title string, date string) (bool, error) {
+	var exists bool
+	err := s.db.Get(&exists,
+		`SELECT EXISTS (SELECT 1 FROM scheduler WHERE user_id = ? AND title = ? AND date = ? AND deleted_at = '')`,
+		userID, title, date,
+	)
+	return exists, err
+}
+
+func (s *TaskStorage) GetTrash(userID int64, limit int) ([]models.Task, error) {
+	var tasks []models.Task
+	err := s.db.Select(&tasks,
+		`SELECT `+taskColumns+`
+		FROM scheduler WHERE user_id = ? AND deleted_at != '' ORDER BY deleted_at DESC, id DESC LIMIT ?`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, s.attachTags(tasks)
+}
+
+// MoveTask переносит задачу в другой проект.
+func (s *TaskStorage) MoveTask(userID int64, id string, projectID string) error {
+	res, err := s.db.Exec(`UPDATE scheduler SET project_id = ? WHERE id = ? AND user_id = ? AND deleted_at = ''`,
+		projectID, id, userID)
 	if err != nil {
 		return err
 	}
@@ -107,6 +328,48 @@ func (s *TaskStorage) DeleteTask(id string) error {
 	return nil
 }
 
+func (s *TaskStorage) RestoreTask(userID int64, id string) error {
+	res, err := s.db.Exec(`UPDATE scheduler SET deleted_at = '' WHERE id = ? AND user_id = ? AND deleted_at != ''`,
+		id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("задача не найдена в корзине")
+	}
+	return nil
+}
+
+// PurgeTrash удаляет из корзины пользователя задачу id или все задачи, если id пуст.
+func (s *TaskStorage) PurgeTrash(userID int64, id string) (int64, error) {
+	query := `DELETE FROM scheduler WHERE user_id = ? AND deleted_at != ''`
+	args := []any{userID}
+	if id != "" {
+		query += ` AND id = ?`
+		args = append(args, id)
+	}
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeDeletedBefore удаляет задачи всех пользователей, попавшие в корзину раньше before.
+func (s *TaskStorage) PurgeDeletedBefore(before string) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM scheduler WHERE deleted_at != '' AND deleted_at < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *TaskStorage) Close() error {
 	return s.db.Close()
 }
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// TagSeparator разделяет теги в параметре запроса и в снимке задачи в истории выполнения.
+const TagSeparator = ","
+
+// setTaskTags заменяет теги задачи, создавая недостающие теги пользователя.
+func setTaskTags(tx *sqlx.Tx, userID int64, taskID any, tags []string) error {
+	_, err := tx.Exec(`DELETE FROM task_tags WHERE task_id = ?`, taskID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		_, err = tx.Exec(`INSERT OR IGNORE INTO tags (user_id, name) VALUES (?, ?)`, userID, tag)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			`INSERT OR IGNORE INTO task_tags (task_id, tag_id)
+			SELECT ?, id FROM tags WHERE user_id = ? AND name = ?`,
+			taskID, userID, tag,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachTags загружает теги для списка задач одним запросом.
+func (s *TaskStorage) attachTags(tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.Id
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT task_tags.task_id, tags.name FROM task_tags
+		JOIN tags ON tags.id = task_tags.tag_id
+		WHERE task_tags.task_id IN (?) ORDER BY tags.name`,
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		TaskId string `db:"task_id"`
+		Name   string `db:"name"`
+	}
+	err = s.db.Select(&rows, s.db.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string][]string)
+	for _, row := range rows {
+		tags[row.TaskId] = append(tags[row.TaskId], row.Name)
+	}
+	for i := range tasks {
+		tasks[i].Tags = tags[tasks[i].Id]
+	}
+	return nil
+}
+
+// tagsCondition отбирает задачи с любым из тегов или, при mode "and", со всеми тегами.
+func tagsCondition(userID int64, tags []string, mode string) (string, []any) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tags)), ", ")
+	args := []any{userID}
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+
+	condition := `id IN (SELECT task_tags.task_id FROM task_tags
+		JOIN tags ON tags.id = task_tags.tag_id
+		WHERE tags.user_id = ? AND tags.name IN (` + placeholders + `)`
+	if mode == "and" {
+		condition += ` GROUP BY task_tags.task_id HAVING COUNT(*) = ?`
+		args = append(args, len(tags))
+	}
+	return condition + `)`, args
+}
+
+func joinTags(tags []string) string {
+	return strings.Join(tags, TagSeparator)
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, TagSeparator)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserStorage struct {
+	db *sqlx.DB
+}
+
+func NewUserStorage(db *sqlx.DB) *UserStorage {
+	return &UserStorage{db: db}
+}
+
+func (s *UserStorage) AddUser(user models.User) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO users (login, password_hash) VALUES (?, ?)`,
+		user.Login, user.PasswordHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (s *UserStorage) GetUserByLogin(login string) (models.User, error) {
+	var user models.User
+	err := s.db.Get(&user, `SELECT id, login, password_hash FROM users WHERE login = ?`, login)
+	if err == sql.ErrNoRows {
+		return user, errors.New("пользователь не найден")
+	}
+	return user, err
+}
+
+func (s *UserStorage) LoginExists(login string) (bool, error) {
+	var exists bool
+	err := s.db.Get(&exists, `SELECT count(*) > 0 FROM users WHERE login = ?`, login)
+	return exists, err
+}
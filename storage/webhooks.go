@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Yandex-Practicum/final-project/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// webhookRow - подписка в виде строки БД: события хранятся через запятую.
+type webhookRow struct {
+	models.Webhook
+	Events string `db:"events"`
+}
+
+type deliveryRow struct {
+	models.WebhookDelivery
+	Payload string `db:"payload"`
+}
+
+// DeliveryJob - доставка, которую пора отправить, вместе с адресом и секретом подписки.
+type DeliveryJob struct {
+	Id        int64  `db:"id"`
+	WebhookId int64  `db:"webhook_id"`
+	Url       string `db:"url"`
+	Secret    string `db:"secret"`
+	Event     string `db:"event"`
+	Payload   string `db:"payload"`
+	Attempts  int    `db:"attempts"`
+}
+
+type WebhookStorage struct {
+	db *sqlx.DB
+}
+
+func NewWebhookStorage(db *sqlx.DB) *WebhookStorage {
+	return &WebhookStorage{db: db}
+}
+
+func (s *WebhookStorage) AddWebhook(userID int64, webhook models.Webhook) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO webhooks (user_id, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?)`,
+		userID, webhook.Url, webhook.Secret, strings.Join(webhook.Events, ","), webhook.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetWebhooks возвращает подписки пользователя без секретов.
+func (s *WebhookStorage) GetWebhooks(userID int64) ([]models.Webhook, error) {
+	var rows []webhookRow
+	err := s.db.Select(&rows,
+		`SELECT id, url, '' AS secret, events, created_at FROM webhooks WHERE user_id = ? ORDER BY id`,
+		userID,
+	)
+	return webhooks(rows), err
+}
+
+// Subscribers возвращает подписки пользователя на событие event вместе с секретами.
+func (s *WebhookStorage) Subscribers(userID int64, event string) ([]models.Webhook, error) {
+	var rows []webhookRow
+	err := s.db.Select(&rows,
+		`SELECT id, url, secret, events, created_at FROM webhooks
+		WHERE user_id = ? AND (events = '' OR ',' || events || ',' LIKE '%,' || ? || ',%')`,
+		userID, event,
+	)
+	return webhooks(rows), err
+}
+
+func (s *WebhookStorage) DeleteWebhook(userID int64, id string) error {
+	res, err := s.db.Exec(`DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("подписка не найдена")
+	}
+	return nil
+}
+
+func (s *WebhookStorage) AddDelivery(delivery models.WebhookDelivery) error {
+	_, err := s.db.Exec(
+		`INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		delivery.WebhookId, delivery.Event, string(delivery.Payload), DeliveryPending,
+		delivery.NextAttemptAt, delivery.CreatedAt,
+	)
+	return err
+}
+
+// DueDeliveries возвращает ожидающие доставки, время попытки которых наступило,
+// кроме доставок подписок busy, которые ещё отправляются. От каждой подписки берётся
+// только самая ранняя ожидающая доставка: пока она не доставлена или не отклонена
+// окончательно, следующие события подписки ждут, и порядок событий сохраняется.
+func (s *WebhookStorage) DueDeliveries(now int64, limit int, busy []int64) ([]DeliveryJob, error) {
+	query := `SELECT d.id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.attempts
+		FROM webhook_deliveries AS d JOIN webhooks AS w ON w.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+			AND d.id = (SELECT MIN(id) FROM webhook_deliveries WHERE webhook_id = d.webhook_id AND status = ?)`
+	args := []any{DeliveryPending, now, DeliveryPending}
+	if len(busy) > 0 {
+		query += ` AND d.webhook_id NOT IN (?)`
+		args = append(args, busy)
+	}
+	query, args, err := sqlx.In(query+` ORDER BY d.next_attempt_at, d.id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []DeliveryJob
+	err = s.db.Select(&jobs, s.db.Rebind(query), args...)
+	return jobs, err
+}
+
+// SaveAttempt записывает результат попытки доставки.
+func (s *WebhookStorage) SaveAttempt(delivery models.WebhookDelivery) error {
+	_, err := s.db.Exec(
+		`UPDATE webhook_deliveries SET status = ?, attempts = ?, response_code = ?, error = ?,
+			next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?`,
+		delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.Error,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.Id,
+	)
+	return err
+}
+
+// GetDeliveries возвращает журнал доставок пользователя, новые записи первыми.
+func (s *WebhookStorage) GetDeliveries(userID int64, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	query := `SELECT d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_code, d.error,
+			d.next_attempt_at, d.created_at, d.delivered_at
+		FROM webhook_deliveries AS d JOIN webhooks AS w ON w.id = d.webhook_id
+		WHERE w.user_id = ?`
+	args := []any{userID}
+	if webhookID != "" {
+		query += ` AND d.webhook_id = ?`
+		args = append(args, webhookID)
+	}
+	query += ` ORDER BY d.id DESC LIMIT ?`
+	args = append(args, limit)
+
+	var rows []deliveryRow
+	err := s.db.Select(&rows, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]models.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		delivery := row.WebhookDelivery
+		delivery.Payload = json.RawMessage(row.Payload)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery ставит доставку в очередь на немедленную повторную отправку.
+func (s *WebhookStorage) RetryDelivery(userID int64, id string, now int64) error {
+	res, err := s.db.Exec(
+		`UPDATE webhook_deliveries SET status = ?, next_attempt_at = ?
+		WHERE id = ? AND status != ? AND webhook_id IN (SELECT id FROM webhooks WHERE user_id = ?)`,
+		DeliveryPending, now, id, DeliveryDelivered, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("доставка не найдена или уже выполнена")
+	}
+	return nil
+}
+
+func webhooks(rows []webhookRow) []models.Webhook {
+	webhooks := make([]models.Webhook, 0, len(rows))
+	for _, row := range rows {
+		webhook := row.Webhook
+		webhook.Events = []string{}
+		if row.Events != "" {
+			webhook.Events = strings.Split(row.Events, ",")
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks
+}
@@ -8,12 +8,42 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+var (
+	testToken     string
+	testTokenOnce sync.Once
+)
+
+// authToken возвращает Token из settings.go, а если он не задан - токен
+// пользователя, которого тесты регистрируют один раз на весь запуск.
+func authToken() string {
+	if len(Token) > 0 {
+		return Token
+	}
+	testTokenOnce.Do(func() {
+		data, err := json.Marshal(map[string]any{"login": fmt.Sprint("tests", time.Now().UnixNano()), "password": "secret1"})
+		if err != nil {
+			return
+		}
+		resp, err := http.Post(getURL("api/signup"), "application/json", bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		var m map[string]any
+		if json.NewDecoder(resp.Body).Decode(&m) == nil {
+			testToken, _ = m["token"].(string)
+		}
+	})
+	return testToken
+}
+
 func requestJSON(apipath string, values map[string]any, method string) ([]byte, error) {
 	var (
 		data []byte
@@ -35,7 +65,7 @@ func requestJSON(apipath string, values map[string]any, method string) ([]byte,
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
-	if len(Token) > 0 {
+	if token := authToken(); len(token) > 0 {
 		jar, err := cookiejar.New(nil)
 		if err != nil {
 			return nil, err
@@ -43,7 +73,7 @@ func requestJSON(apipath string, values map[string]any, method string) ([]byte,
 		jar.SetCookies(req.URL, []*http.Cookie{
 			{
 				Name:  "token",
-				Value: Token,
+				Value: token,
 			},
 		})
 		client.Jar = jar
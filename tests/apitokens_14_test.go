@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func requestBearer(token string, apipath string, values map[string]any, method string) (map[string]any, error) {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	err = json.Unmarshal(body, &m)
+	return m, err
+}
+
+func TestAPITokens(t *testing.T) {
+	login := fmt.Sprint("tokens", time.Now().UnixNano())
+	session := signUp(t, login, "secret1")
+
+	for _, v := range []map[string]any{
+		{"name": "", "scope": "read"},
+		{"name": "bot", "scope": "admin"},
+	} {
+		m, err := requestAs(session, "api/tokens", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %v", v)
+	}
+	m, err := requestBearer("", "api/tokens", map[string]any{"name": "anonymous"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, "auth_required", m["code"])
+	m, err = requestBearer("garbage", "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "auth_required", m["code"])
+
+	m, err = requestAs(session, "api/tokens", map[string]any{"name": "cron"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, "write", m["scope"])
+	writeToken := fmt.Sprint(m["token"])
+
+	m, err = requestAs(session, "api/tokens", map[string]any{"name": "dashboard", "scope": "read"}, http.MethodPost)
+	assert.NoError(t, err)
+	readToken := fmt.Sprint(m["token"])
+	readID := fmt.Sprint(m["id"])
+
+	m, err = requestAs(session, "api/tokens", nil, http.MethodGet)
+	assert.NoError(t, err)
+	list, ok := m["tokens"].([]any)
+	assert.True(t, ok)
+	assert.Len(t, list, 2)
+	for _, item := range list {
+		assert.NotContains(t, item, "token")
+	}
+
+	m, err = requestBearer(writeToken, "api/task", map[string]any{"title": "Из cron"}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(m["id"])
+	assert.NotEmpty(t, id)
+
+	m, err = requestBearer(readToken, "api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Из cron", m["title"])
+	m, err = requestBearer(readToken, "api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_read_only", m["code"])
+
+	m, err = requestBearer(writeToken, "api/tokens", map[string]any{"name": "escalation"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(session, "api/tokens?id="+readID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestBearer(readToken, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_invalid", m["code"])
+
+	m, err = requestBearer(session, "api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	notFoundTask(t, id)
+}
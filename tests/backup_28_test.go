@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rawAs(t *testing.T, token, method, path string, body []byte) []byte {
+	req, err := http.NewRequest(method, getURL(path), bytes.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return data
+}
+
+func importTasks(t *testing.T, token, params string, body []byte) map[string]any {
+	var m map[string]any
+	data := rawAs(t, token, http.MethodPost, "api/tasks/bulk?"+params, body)
+	assert.NoError(t, json.Unmarshal(data, &m), string(data))
+	return m
+}
+
+func TestBackup(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("backup%d", time.Now().UnixNano()), "secret1")
+
+	var ids []string
+	for _, v := range []map[string]any{
+		{"title": "Бассейн", "date": "20300107", "repeat": "w 1,4", "time": "07:30", "timezone": "Europe/Moscow", "tags": []string{"спорт"}},
+		{"title": "Налоги, \"декларация\"", "date": "20300401", "comment": "до 30 апреля\nне забыть", "priority": "urgent"},
+	} {
+		m, err := requestAs(token, "api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Nil(t, m["error"])
+		ids = append(ids, fmt.Sprint(m["id"]))
+	}
+	for _, title := range []string{"Очки", "Шапочка"} {
+		m, err := requestAs(token, "api/task/checklist", map[string]any{"task_id": ids[0], "title": title}, http.MethodPost)
+		assert.NoError(t, err)
+		if title == "Очки" {
+			_, err = requestAs(token, "api/task/checklist/check?id="+fmt.Sprint(m["id"]), nil, http.MethodPost)
+			assert.NoError(t, err)
+		}
+	}
+	m, err := requestAs(token, "api/task/dependency", map[string]any{"task_id": ids[1], "depends_on": ids[0]}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	var backup struct {
+		Tasks []map[string]any `json:"tasks"`
+	}
+	data := rawAs(t, token, http.MethodGet, "api/tasks/export", nil)
+	assert.NoError(t, json.Unmarshal(data, &backup), string(data))
+	if !assert.Len(t, backup.Tasks, 2) {
+		return
+	}
+	swim := backup.Tasks[0]
+	assert.Equal(t, "Бассейн", swim["title"])
+	assert.Equal(t, "w 1,4", swim["repeat"])
+	assert.Equal(t, "07:30", swim["time"])
+	assert.Equal(t, []any{"спорт"}, swim["tags"])
+	assert.NotEmpty(t, swim["project_id"])
+	if checklist, ok := swim["checklist"].([]any); assert.True(t, ok) && assert.Len(t, checklist, 2) {
+		assert.Equal(t, "Очки", checklist[0].(map[string]any)["title"])
+		assert.Equal(t, true, checklist[0].(map[string]any)["done"])
+	}
+	assert.Equal(t, []any{ids[0]}, backup.Tasks[1]["depends_on"])
+	assert.Nil(t, backup.Tasks[1]["blocked_by"])
+
+	data = rawAs(t, token, http.MethodGet, "api/tasks/export?format=csv", nil)
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	assert.NoError(t, err)
+	if !assert.Len(t, records, 3) {
+		return
+	}
+	assert.Equal(t, []string{"id", "date", "title", "comment", "repeat", "repeat_until", "repeat_count",
+		"time", "timezone", "priority", "project_id", "tags", "checklist", "depends_on"}, records[0])
+	assert.Equal(t, swim["id"], records[1][0])
+	assert.Equal(t, "Налоги, \"декларация\"", records[2][2])
+	assert.Equal(t, "до 30 апреля\nне забыть", records[2][3])
+	assert.Equal(t, "urgent", records[2][9])
+	assert.Equal(t, "[x] Очки\n[ ] Шапочка", records[1][12])
+	assert.Equal(t, ids[0], records[2][13])
+
+	m = importTasks(t, token, "format=json", data)
+	assert.NotEmpty(t, m["error"])
+	m = importTasks(t, token, "format=json&mode=replace", nil)
+	assert.NotEmpty(t, m["error"])
+
+	jsonBackup, err := json.Marshal(map[string]any{"tasks": append(backup.Tasks,
+		map[string]any{"title": "Неверная дата", "date": "31.12.2030"},
+		map[string]any{"date": "20300101"},
+		map[string]any{"title": "Неверное правило", "date": "20200101", "repeat": "q 1"},
+	)})
+	assert.NoError(t, err)
+	m = importTasks(t, token, "format=json&mode=append", jsonBackup)
+	assert.EqualValues(t, 2, m["created"])
+	assert.EqualValues(t, 0, m["updated"])
+	assert.EqualValues(t, 3, m["failed"])
+	rows := []float64{}
+	for _, e := range m["errors"].([]any) {
+		rows = append(rows, e.(map[string]any)["row"].(float64))
+	}
+	assert.Equal(t, []float64{3, 4, 5}, rows)
+
+	// Копия задачи зависит от копии, а не от исходной задачи.
+	m, err = requestAs(token, "api/tasks?search=декларация", nil, http.MethodGet)
+	assert.NoError(t, err)
+	blockers := []string{}
+	for _, v := range m["tasks"].([]any) {
+		task, err := requestAs(token, "api/task?id="+fmt.Sprint(v.(map[string]any)["id"]), nil, http.MethodGet)
+		assert.NoError(t, err)
+		if blockedBy, ok := task["blocked_by"].([]any); assert.True(t, ok) && assert.Len(t, blockedBy, 1) {
+			blockers = append(blockers, fmt.Sprint(blockedBy[0].(map[string]any)["id"]))
+		}
+		assert.Empty(t, task["checklist"])
+	}
+	if assert.Len(t, blockers, 2) {
+		assert.NotEqual(t, blockers[0], blockers[1])
+	}
+
+	records[1][2] = "Бассейн утром"
+	records[1][11] = "спорт,здоровье"
+	records[1][12] = "[x] Очки\nПолотенце"
+	records = append(records, []string{"", "20300110", "Новая из CSV", "", "", "", "", "", "", "low", "", ""})
+	records = append(records, []string{"", "20300110", "Неверное число", "", "d 1", "", "много", "", "", "", "", ""})
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	assert.NoError(t, writer.WriteAll(records))
+	m = importTasks(t, token, "format=csv&mode=merge", buf.Bytes())
+	assert.EqualValues(t, 1, m["created"])
+	assert.EqualValues(t, 2, m["updated"])
+	assert.EqualValues(t, 1, m["failed"])
+	if errors, ok := m["errors"].([]any); assert.True(t, ok) && assert.Len(t, errors, 1) {
+		assert.EqualValues(t, 4, errors[0].(map[string]any)["row"])
+	}
+
+	m, err = requestAs(token, "api/task?id="+fmt.Sprint(swim["id"]), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Бассейн утром", m["title"])
+	assert.ElementsMatch(t, []any{"спорт", "здоровье"}, m["tags"])
+	assert.Equal(t, "w 1,4", m["repeat"])
+	if checklist, ok := m["checklist"].([]any); assert.True(t, ok) && assert.Len(t, checklist, 2) {
+		assert.Equal(t, true, checklist[0].(map[string]any)["done"])
+		assert.Equal(t, "Полотенце", checklist[1].(map[string]any)["title"])
+		assert.Equal(t, false, checklist[1].(map[string]any)["done"])
+	}
+
+	m, err = requestAs(token, "api/tasks?limit=50", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, m["tasks"], 5)
+
+	// Задача, удалённая навсегда, в режиме merge возвращается со своим id.
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Временная", "date": "20300105"}, http.MethodPost)
+	assert.NoError(t, err)
+	tempID := fmt.Sprint(m["id"])
+	data = rawAs(t, token, http.MethodGet, "api/tasks/export", nil)
+	_, err = requestAs(token, "api/task?id="+tempID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	_, err = requestAs(token, "api/trash?id="+tempID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	m = importTasks(t, token, "format=json&mode=merge", data)
+	assert.EqualValues(t, 1, m["created"])
+	assert.EqualValues(t, 5, m["updated"])
+	assert.Empty(t, m["errors"])
+	m, err = requestAs(token, "api/task?id="+tempID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Временная", m["title"])
+
+	// Проекты не выгружаются: в другом аккаунте задачи попадают во «Входящие».
+	for _, mode := range []string{"append", "merge"} {
+		other := signUp(t, fmt.Sprintf("backup%s%d", mode, time.Now().UnixNano()), "secret1")
+		m = importTasks(t, other, "format=json&mode="+mode, data)
+		assert.EqualValues(t, 6, m["created"], mode)
+		assert.EqualValues(t, 0, m["failed"], mode)
+		assert.Empty(t, m["errors"], mode)
+
+		m, err = requestAs(other, "api/projects", nil, http.MethodGet)
+		assert.NoError(t, err)
+		projects, _ := m["projects"].([]any)
+		if !assert.Len(t, projects, 1) {
+			continue
+		}
+		inbox := projects[0].(map[string]any)["id"]
+		m, err = requestAs(other, "api/tasks?limit=50", nil, http.MethodGet)
+		assert.NoError(t, err)
+		if assert.Len(t, m["tasks"], 6, mode) {
+			for _, v := range m["tasks"].([]any) {
+				assert.Equal(t, inbox, v.(map[string]any)["project_id"])
+			}
+		}
+	}
+}
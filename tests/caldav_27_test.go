@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/ical"
+	"github.com/stretchr/testify/assert"
+)
+
+// davClient - простой клиент CalDAV, авторизуется API-токеном через Basic.
+type davClient struct {
+	t     *testing.T
+	token string
+}
+
+type davMultistatus struct {
+	Responses []struct {
+		Href         string `xml:"DAV: href"`
+		Status       string `xml:"DAV: status"`
+		ETag         string `xml:"DAV: propstat>prop>getetag"`
+		CalendarData string `xml:"urn:ietf:params:xml:ns:caldav propstat>prop>calendar-data"`
+		CTag         string `xml:"http://calendarserver.org/ns/ propstat>prop>getctag"`
+	} `xml:"DAV: response"`
+}
+
+func (c davClient) do(method, path, body string, headers map[string]string) (*http.Response, string) {
+	req, err := http.NewRequest(method, getURL(path), strings.NewReader(body))
+	assert.NoError(c.t, err)
+	req.SetBasicAuth("caldav", c.token)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(c.t, err) {
+		c.t.FailNow()
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(c.t, err)
+	return resp, string(data)
+}
+
+func (c davClient) multistatus(method, path, depth, body string) davMultistatus {
+	resp, data := c.do(method, path, body, map[string]string{"Depth": depth, "Content-Type": "application/xml"})
+	assert.Equal(c.t, http.StatusMultiStatus, resp.StatusCode, data)
+	var ms davMultistatus
+	assert.NoError(c.t, xml.Unmarshal([]byte(data), &ms), data)
+	return ms
+}
+
+func (c davClient) put(path, ics string, headers map[string]string) *http.Response {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Content-Type"] = "text/calendar"
+	resp, _ := c.do(http.MethodPut, path, ics, headers)
+	return resp
+}
+
+func vtodo(uid string, lines ...string) string {
+	return strings.Join(append(append([]string{
+		"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//test//RU", "BEGIN:VTODO", "UID:" + uid,
+	}, lines...), "END:VTODO", "END:VCALENDAR"), "\r\n")
+}
+
+func TestCalDAV(t *testing.T) {
+	session := signUp(t, fmt.Sprintf("caldav%d", time.Now().UnixNano()), "secret1")
+	m, err := requestAs(session, "api/tokens", map[string]any{"name": "phone"}, http.MethodPost)
+	assert.NoError(t, err)
+	c := davClient{t: t, token: fmt.Sprint(m["token"])}
+
+	m, err = requestAs(session, "api/task", map[string]any{"title": "Из API", "date": "20300110"}, http.MethodPost)
+	assert.NoError(t, err)
+	apiID := fmt.Sprint(m["id"])
+
+	resp, _ := c.do(http.MethodOptions, "caldav/", "", nil)
+	assert.Contains(t, resp.Header.Get("DAV"), "calendar-access")
+
+	ms := c.multistatus("PROPFIND", "caldav/", "1", "")
+	assert.Len(t, ms.Responses, 2)
+	ms = c.multistatus("PROPFIND", "caldav/tasks/", "1", "")
+	if assert.Len(t, ms.Responses, 2) {
+		assert.NotEmpty(t, ms.Responses[0].CTag)
+		assert.Equal(t, "/caldav/tasks/"+apiID+".ics", ms.Responses[1].Href)
+	}
+	ctag := ms.Responses[0].CTag
+
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:С телефона", "DUE;VALUE=DATE:20300111",
+		"RRULE:FREQ=WEEKLY;BYDAY=FR", "CATEGORIES:дом"), map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:Повтор"), map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	resp, data := c.do(http.MethodGet, "caldav/tasks/phone-1.ics", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	assert.NotEmpty(t, etag)
+	calendar, err := ical.Parse(strings.NewReader(data))
+	if assert.NoError(t, err) && assert.Len(t, calendar.Find("VTODO"), 1) {
+		todo := calendar.Find("VTODO")[0]
+		assert.Equal(t, "phone-1", todo.Value("UID"))
+		assert.Equal(t, "С телефона", todo.Value("SUMMARY"))
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=FR;WKST=MO", todo.Value("RRULE"))
+	}
+
+	ms = c.multistatus("PROPFIND", "caldav/tasks/", "0", "")
+	if assert.Len(t, ms.Responses, 1) {
+		assert.NotEqual(t, ctag, ms.Responses[0].CTag)
+	}
+
+	m, err = requestAs(session, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, m["tasks"], 2)
+
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:Чужая правка"), map[string]string{"If-Match": `"stale"`})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:Уборка", "DUE;VALUE=DATE:20300111",
+		"RRULE:FREQ=WEEKLY;BYDAY=FR"), map[string]string{"If-Match": `"` + etag + `"`})
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.NotEqual(t, etag, strings.Trim(resp.Header.Get("ETag"), `"`))
+
+	ms = c.multistatus("REPORT", "caldav/tasks/", "1", `<?xml version="1.0"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO"/></c:comp-filter></c:filter>
+</c:calendar-query>`)
+	summaries := map[string]string{}
+	for _, r := range ms.Responses {
+		calendar, err := ical.Parse(strings.NewReader(r.CalendarData))
+		if assert.NoError(t, err) {
+			summaries[r.Href] = calendar.Find("VTODO")[0].Value("SUMMARY")
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"/caldav/tasks/" + apiID + ".ics": "Из API",
+		"/caldav/tasks/phone-1.ics":       "Уборка",
+	}, summaries)
+
+	ms = c.multistatus("REPORT", "caldav/tasks/", "1", `<?xml version="1.0"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT"/></c:comp-filter></c:filter>
+</c:calendar-query>`)
+	assert.Empty(t, ms.Responses)
+
+	ms = c.multistatus("REPORT", "caldav/tasks/", "1", `<?xml version="1.0"?>
+<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <d:href>/caldav/tasks/phone-1.ics</d:href>
+  <d:href>/caldav/tasks/missing.ics</d:href>
+</c:calendar-multiget>`)
+	if assert.Len(t, ms.Responses, 2) {
+		assert.NotEmpty(t, ms.Responses[0].CalendarData)
+		assert.Contains(t, ms.Responses[1].Status, "404")
+	}
+
+	m, err = requestAs(session, "api/tasks?search=Уборка", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var phoneID string
+	if list, _ := m["tasks"].([]any); assert.Len(t, list, 1) {
+		phoneID = fmt.Sprint(list[0].(map[string]any)["id"])
+	}
+	dependency := map[string]any{"task_id": phoneID, "depends_on": apiID}
+	m, err = requestAs(session, "api/task/dependency", dependency, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:Уборка готова", "DUE;VALUE=DATE:20300111",
+		"RRULE:FREQ=WEEKLY;BYDAY=FR", "STATUS:COMPLETED"), nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, data = c.do(http.MethodGet, "caldav/tasks/phone-1.ics", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, data, "SUMMARY:Уборка\r\n")
+	m, err = requestAs(session, "api/task/dependency?task_id="+phoneID+"&depends_on="+apiID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:Уборка", "DUE;VALUE=DATE:20300111",
+		"RRULE:FREQ=WEEKLY;BYDAY=FR", "STATUS:COMPLETED"), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, data = c.do(http.MethodGet, "caldav/tasks/phone-1.ics", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, data, "DUE;VALUE=DATE:20300118")
+	assert.NotContains(t, data, "COMPLETED")
+
+	resp = c.put("caldav/tasks/"+apiID+".ics", vtodo("task-"+apiID, "SUMMARY:Из API", "DUE;VALUE=DATE:20300110",
+		"STATUS:COMPLETED"), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = c.do(http.MethodGet, "caldav/tasks/"+apiID+".ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = c.do(http.MethodDelete, "caldav/tasks/phone-1.ics", "", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	ms = c.multistatus("PROPFIND", "caldav/tasks/", "1", "")
+	assert.Len(t, ms.Responses, 1)
+	m, err = requestAs(session, "api/trash", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, m["tasks"], 1)
+
+	for _, status := range []string{"STATUS:COMPLETED", "STATUS:CANCELLED"} {
+		resp = c.put("caldav/tasks/done-1.ics", vtodo("done-1", "SUMMARY:Уже сделано", "DUE;VALUE=DATE:20300112", status), nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("ETag"))
+		resp, _ = c.do(http.MethodGet, "caldav/tasks/done-1.ics", "", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+
+	resp = c.put("caldav/tasks/phone-1.ics", vtodo("phone-1", "SUMMARY:Снова уборка", "DUE;VALUE=DATE:20300112"),
+		map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, data = c.do(http.MethodGet, "caldav/tasks/phone-1.ics", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, data, "SUMMARY:Снова уборка")
+	ms = c.multistatus("PROPFIND", "caldav/tasks/", "1", "")
+	assert.Len(t, ms.Responses, 2)
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Yandex-Practicum/final-project/ical"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("calendar%d", time.Now().UnixNano()), "secret1")
+
+	ids := map[string]string{}
+	for _, v := range []map[string]any{
+		{"title": "Планёрка", "date": "20300107", "repeat": "w 1,3 /2", "time": "10:00", "timezone": "Europe/Moscow", "tags": []string{"работа"}},
+		{"title": "Отчёт", "date": "20300131", "repeat": "m -1", "repeat_until": "20300630"},
+		{"title": "Сверка", "date": "20300107", "repeat": "b 5", "repeat_count": 3},
+		{"title": "Купить молоко, хлеб", "date": "20300108", "priority": "urgent"},
+	} {
+		m, err := requestAs(token, "api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Nil(t, m["error"], "Ошибка для %v", v)
+		ids[v["title"].(string)] = fmt.Sprint(m["id"])
+	}
+
+	body, err := getBody("api/tasks.ics?token=" + token)
+	assert.NoError(t, err)
+	calendar, err := ical.Parse(bytes.NewReader(body))
+	if !assert.NoError(t, err, string(body)) {
+		return
+	}
+	events := map[string]*ical.Component{}
+	for _, event := range calendar.Find("VEVENT") {
+		events[event.Value("UID")] = event
+	}
+	assert.Len(t, events, 6)
+
+	event := events["task-"+ids["Планёрка"]]
+	if assert.NotNil(t, event) {
+		start, _ := event.Get("DTSTART")
+		assert.Equal(t, "20300107T100000", start.Value)
+		assert.Equal(t, "Europe/Moscow", start.Params["TZID"])
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;WKST=MO", event.Value("RRULE"))
+		assert.Equal(t, "работа", event.Value("CATEGORIES"))
+	}
+
+	event = events["task-"+ids["Отчёт"]]
+	if assert.NotNil(t, event) {
+		start, _ := event.Get("DTSTART")
+		assert.Equal(t, "20300131", start.Value)
+		assert.True(t, start.IsDate())
+		assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20300630", event.Value("RRULE"))
+	}
+
+	for _, date := range []string{"20300107", "20300114", "20300121"} {
+		event = events["task-"+ids["Сверка"]+"-"+date]
+		if assert.NotNil(t, event, date) {
+			assert.Empty(t, event.Value("RRULE"))
+		}
+	}
+
+	event = events["task-"+ids["Купить молоко, хлеб"]]
+	if assert.NotNil(t, event) {
+		assert.Equal(t, `Купить молоко\, хлеб`, event.Value("SUMMARY"))
+		assert.Equal(t, "Купить молоко, хлеб", ical.Unescape(event.Value("SUMMARY")))
+		assert.Equal(t, "1", event.Value("PRIORITY"))
+	}
+
+	body, err = getBody("api/tasks.ics?todo=true&token=" + token)
+	assert.NoError(t, err)
+	calendar, err = ical.Parse(bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Len(t, calendar.Find("VTODO"), 6)
+	assert.Empty(t, calendar.Find("VEVENT"))
+	for _, todo := range calendar.Find("VTODO") {
+		due, ok := todo.Get("DUE")
+		assert.True(t, ok)
+		if todo.Value("RRULE") == "" {
+			continue
+		}
+		start, ok := todo.Get("DTSTART")
+		if assert.True(t, ok, "RRULE без DTSTART у %s", todo.Value("UID")) {
+			assert.Equal(t, due.Value, start.Value)
+			assert.Equal(t, due.Params, start.Params)
+		}
+	}
+}
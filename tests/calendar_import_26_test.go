@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func importCalendar(t *testing.T, token string, ics string) map[string]any {
+	req, err := http.NewRequest(http.MethodPost, getURL("api/tasks/import"), bytes.NewBufferString(ics))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "text/calendar")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m), string(body))
+	return m
+}
+
+func TestCalendarImport(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("import%d", time.Now().UnixNano()), "secret1")
+	now := time.Now()
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTODO",
+		"SUMMARY:Позвонить",
+		"DUE;VALUE=DATE:20300110",
+		"PRIORITY:1",
+		"CATEGORIES:Дом,звонки",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Уже сделано",
+		"STATUS:COMPLETED",
+		"END:VTODO",
+		"BEGIN:VEVENT",
+		"SUMMARY:Планёрка",
+		"DTSTART;TZID=Europe/Moscow:20300107T100000",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Отчёт",
+		"DESCRIPTION:За месяц\\, кратко",
+		"DTSTART;VALUE=DATE:20300131",
+		"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=5",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Инвентаризация",
+		"DTSTART;VALUE=DATE:20300329",
+		"RRULE:FREQ=MONTHLY;BYDAY=-1FR;BYMONTH=3,6",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Последний рабочий день",
+		"DTSTART;VALUE=DATE:20300131",
+		"RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Каждый час",
+		"DTSTART:20300107T100000Z",
+		"RRULE:FREQ=HOURLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Прошедшая встреча",
+		"DTSTART:20200107T100000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20300107",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Три дня в 2020",
+		"DTSTART;VALUE=DATE:20200101",
+		"RRULE:FREQ=DAILY;COUNT=3",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"SUMMARY:Каждую неделю до марта 2020",
+		"DUE;VALUE=DATE:20200101",
+		"RRULE:FREQ=WEEKLY;UNTIL=20200301",
+		"END:VTODO",
+		"BEGIN:VEVENT",
+		"SUMMARY:Зарядка",
+		"DTSTART;VALUE=DATE:" + now.AddDate(0, 0, -2).Format(`20060102`),
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	m := importCalendar(t, token, ics)
+	assert.EqualValues(t, 6, m["created"])
+	assert.EqualValues(t, 4, m["skipped"])
+	assert.EqualValues(t, 2, m["failed"])
+	assert.Len(t, m["errors"], 2)
+
+	m = importCalendar(t, token, ics)
+	assert.EqualValues(t, 0, m["created"])
+	assert.EqualValues(t, 10, m["skipped"])
+
+	m, err := requestAs(token, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	tasks := map[string]map[string]any{}
+	list, _ := m["tasks"].([]any)
+	for _, v := range list {
+		task := v.(map[string]any)
+		tasks[fmt.Sprint(task["title"])] = task
+	}
+	assert.Len(t, tasks, 6)
+
+	task := tasks["Позвонить"]
+	assert.Equal(t, "20300110", task["date"])
+	assert.Equal(t, "urgent", task["priority"])
+	assert.Equal(t, []any{"дом", "звонки"}, task["tags"])
+
+	task = tasks["Планёрка"]
+	assert.Equal(t, "w 1,3 /2", task["repeat"])
+	assert.Equal(t, "10:00", task["time"])
+	assert.Equal(t, "Europe/Moscow", task["timezone"])
+
+	task = tasks["Отчёт"]
+	assert.Equal(t, "m -1", task["repeat"])
+	assert.EqualValues(t, 5, task["repeat_count"])
+	assert.Equal(t, "За месяц, кратко", task["comment"])
+
+	assert.Equal(t, "m -1:5 3,6", tasks["Инвентаризация"]["repeat"])
+	assert.Equal(t, "RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", tasks["Последний рабочий день"]["repeat"])
+
+	task = tasks["Зарядка"]
+	assert.Equal(t, now.Format(`20060102`), task["date"])
+	assert.Equal(t, "d 1", task["repeat"])
+	assert.EqualValues(t, 3, task["repeat_count"])
+}
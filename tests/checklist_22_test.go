@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecklist(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("checklist%d", time.Now().UnixNano()), "secret1")
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	m, err := requestAs(token, "api/task", map[string]any{"title": "Релиз", "date": today, "repeat": "d 7"}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(m["id"])
+
+	var ids []string
+	for _, title := range []string{"Поставить тег", "Собрать", "Объявить"} {
+		m, err = requestAs(token, "api/task/checklist", map[string]any{"task_id": taskID, "title": title}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Nil(t, m["error"])
+		ids = append(ids, fmt.Sprint(m["id"]))
+	}
+	items := func() []map[string]any {
+		m, err := requestAs(token, "api/task/checklist?task_id="+taskID, nil, http.MethodGet)
+		assert.NoError(t, err)
+		var items []map[string]any
+		list, _ := m["items"].([]any)
+		for _, v := range list {
+			items = append(items, v.(map[string]any))
+		}
+		return items
+	}
+	titles := func() []string {
+		var titles []string
+		for _, item := range items() {
+			titles = append(titles, fmt.Sprint(item["title"]))
+		}
+		return titles
+	}
+	assert.Equal(t, []string{"Поставить тег", "Собрать", "Объявить"}, titles())
+
+	m, err = requestAs(token, "api/task/checklist/reorder", map[string]any{
+		"task_id": taskID, "ids": []string{ids[1], ids[0], ids[2]},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Equal(t, []string{"Собрать", "Поставить тег", "Объявить"}, titles())
+
+	for _, order := range [][]string{{ids[0], ids[1]}, {ids[0], ids[0], ids[1]}} {
+		m, err = requestAs(token, "api/task/checklist/reorder", map[string]any{"task_id": taskID, "ids": order}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %v", order)
+	}
+
+	for _, id := range ids[:2] {
+		m, err = requestAs(token, "api/task/checklist/check?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, m)
+	}
+	m, err = requestAs(token, "api/task/checklist/uncheck?id="+ids[1], nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	m, err = requestAs(token, "api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	checklist, _ := m["checklist"].([]any)
+	if assert.Len(t, checklist, 3) {
+		assert.Equal(t, true, checklist[1].(map[string]any)["done"])
+		assert.Equal(t, false, checklist[0].(map[string]any)["done"])
+	}
+
+	m, err = requestAs(token, "api/task/done?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	for _, item := range items() {
+		assert.Equal(t, false, item["done"], item["title"])
+	}
+
+	m, err = requestAs(token, "api/task/undone?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"])
+	done := map[string]any{}
+	for _, item := range items() {
+		done[fmt.Sprint(item["title"])] = item["done"]
+	}
+	assert.Equal(t, map[string]any{"Поставить тег": true, "Собрать": false, "Объявить": false}, done)
+
+	m, err = requestAs(token, "api/task/checklist", map[string]any{"id": ids[2], "title": "Объявить в чате"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task/checklist?id="+ids[0], nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Equal(t, []string{"Собрать", "Объявить в чате"}, titles())
+
+	other := signUp(t, fmt.Sprintf("checklistother%d", time.Now().UnixNano()), "secret1")
+	for _, request := range []struct {
+		path   string
+		values map[string]any
+		method string
+	}{
+		{"api/task/checklist?task_id=" + taskID, nil, http.MethodGet},
+		{"api/task/checklist", map[string]any{"task_id": taskID, "title": "Чужой"}, http.MethodPost},
+		{"api/task/checklist/check?id=" + ids[1], nil, http.MethodPost},
+		{"api/task/checklist?id=" + ids[1], nil, http.MethodDelete},
+	} {
+		m, err = requestAs(other, request.path, request.values, request.method)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %s", request.path)
+	}
+	m, err = requestAs(token, "api/task/checklist", map[string]any{"task_id": taskID, "title": "  "}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getCompletions(t *testing.T, token string, params string) []map[string]any {
+	m, err := requestAs(token, "api/completions?"+params, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"], params)
+
+	var completions []map[string]any
+	list, _ := m["completions"].([]any)
+	for _, v := range list {
+		completions = append(completions, v.(map[string]any))
+	}
+	return completions
+}
+
+func TestCompletions(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("history%d", time.Now().UnixNano()), "secret1")
+	now := time.Now()
+	today := now.Format(`20060102`)
+	tomorrow := now.AddDate(0, 0, 1).Format(`20060102`)
+
+	m, err := requestAs(token, "api/task", map[string]any{"date": today, "title": "Разовая"}, http.MethodPost)
+	assert.NoError(t, err)
+	once := fmt.Sprint(m["id"])
+
+	m, err = requestAs(token, "api/task/done?id="+once, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task?id="+once, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	history := getCompletions(t, token, "")
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, once, fmt.Sprint(history[0]["task_id"]))
+		assert.Equal(t, "Разовая", history[0]["title"])
+		assert.Equal(t, today, history[0]["date"])
+		assert.Equal(t, today, history[0]["completed_on"])
+		assert.NotEmpty(t, history[0]["completed_at"])
+	}
+
+	m, err = requestAs(token, "api/task/undone?id="+once, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"])
+	m, err = requestAs(token, "api/task?id="+once, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Разовая", m["title"])
+	assert.Equal(t, today, m["date"])
+	assert.Empty(t, getCompletions(t, token, ""))
+
+	m, err = requestAs(token, "api/task", map[string]any{
+		"date":         today,
+		"title":        "Повторяющаяся",
+		"repeat":       "d 3",
+		"repeat_count": 3,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	repeating := fmt.Sprint(m["id"])
+
+	for i := 0; i < 2; i++ {
+		m, err = requestAs(token, "api/task/done?id="+repeating, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, m)
+	}
+	m, err = requestAs(token, "api/task?id="+repeating, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 6).Format(`20060102`), m["date"])
+	assert.Equal(t, float64(1), m["repeat_count"])
+
+	m, err = requestAs(token, "api/task/undone?id="+repeating, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), m["date"])
+	m, err = requestAs(token, "api/task?id="+repeating, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), m["date"])
+	assert.Equal(t, float64(2), m["repeat_count"])
+
+	assert.Len(t, getCompletions(t, token, "task_id="+repeating), 1)
+	assert.Empty(t, getCompletions(t, token, "task_id="+once))
+	assert.Len(t, getCompletions(t, token, "from="+today+"&to="+today), 1)
+	assert.Empty(t, getCompletions(t, token, "from="+tomorrow))
+
+	other := signUp(t, fmt.Sprintf("stranger%d", time.Now().UnixNano()), "secret1")
+	assert.Empty(t, getCompletions(t, other, ""))
+	m, err = requestAs(other, "api/task/undone?id="+repeating, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(token, "api/task/undone?id="+once, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	for _, params := range []string{"from=2024-01-01", "limit=0"} {
+		m, err = requestAs(token, "api/completions?"+params, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %s", params)
+	}
+}
@@ -11,11 +11,19 @@ import (
 )
 
 type Task struct {
-	ID      int64  `db:"id"`
-	Date    string `db:"date"`
-	Title   string `db:"title"`
-	Comment string `db:"comment"`
-	Repeat  string `db:"repeat"`
+	ID          int64  `db:"id"`
+	Date        string `db:"date"`
+	Title       string `db:"title"`
+	Comment     string `db:"comment"`
+	Repeat      string `db:"repeat"`
+	RepeatUntil string `db:"repeat_until"`
+	RepeatCount int64  `db:"repeat_count"`
+	Time        string `db:"time"`
+	Timezone    string `db:"timezone"`
+	UserID      int64  `db:"user_id"`
+	DeletedAt   string `db:"deleted_at"`
+	Priority    int64  `db:"priority"`
+	ProjectID   int64  `db:"project_id"`
 }
 
 func count(db *sqlx.DB) (int, error) {
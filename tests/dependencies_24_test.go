@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("deps%d", time.Now().UnixNano()), "secret1")
+	today := time.Now().Format(`20060102`)
+
+	add := func(title, repeat string) string {
+		m, err := requestAs(token, "api/task", map[string]any{"title": title, "date": today, "repeat": repeat}, http.MethodPost)
+		assert.NoError(t, err)
+		return fmt.Sprint(m["id"])
+	}
+	review := add("Code review", "d 7")
+	tests := add("Тесты", "")
+	deploy := add("Deploy", "")
+
+	link := func(taskID, dependsOn string) map[string]any {
+		m, err := requestAs(token, "api/task/dependency", map[string]any{"task_id": taskID, "depends_on": dependsOn}, http.MethodPost)
+		assert.NoError(t, err)
+		return m
+	}
+	assert.Empty(t, link(deploy, review))
+	assert.Empty(t, link(deploy, tests))
+	assert.Empty(t, link(tests, review))
+
+	for _, v := range [][2]string{{review, deploy}, {review, tests}, {deploy, deploy}, {deploy, "999999999"}} {
+		assert.NotEmpty(t, link(v[0], v[1])["error"], "Ожидается ошибка для %v", v)
+	}
+
+	refs := func(id, field string) []string {
+		m, err := requestAs(token, "api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		var titles []string
+		list, _ := m[field].([]any)
+		for _, v := range list {
+			titles = append(titles, fmt.Sprint(v.(map[string]any)["title"]))
+		}
+		return titles
+	}
+	assert.Equal(t, []string{"Code review", "Тесты"}, refs(deploy, "blocked_by"))
+	assert.Equal(t, []string{"Тесты", "Deploy"}, refs(review, "blocking"))
+
+	m, err := requestAs(token, "api/task/done?id="+deploy, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(token, "api/task/done?id="+review, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Equal(t, []string{"Тесты"}, refs(deploy, "blocked_by"))
+	assert.Empty(t, refs(review, "blocking"))
+
+	m, err = requestAs(token, "api/task/done?id="+tests, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Empty(t, refs(deploy, "blocked_by"))
+
+	m, err = requestAs(token, "api/task/undone?id="+tests, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"])
+	assert.Equal(t, []string{"Тесты"}, refs(deploy, "blocked_by"))
+
+	m, err = requestAs(token, "api/task/dependency?task_id="+deploy+"&depends_on="+tests, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Empty(t, refs(deploy, "blocked_by"))
+
+	assert.Empty(t, link(deploy, tests))
+	m, err = requestAs(token, "api/task/done?id="+deploy+"&force=true", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func requestRaw(token string, apipath string, data []byte, contentType string, method string) ([]byte, error) {
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := &http.Client{}
+	if len(token) > 0 {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		jar.SetCookies(req.URL, []*http.Cookie{{Name: "token", Value: token}})
+		client.Jar = jar
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func nextDateFor(t *testing.T, date, repeat string) string {
+	body, err := getBody(fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+		url.QueryEscape(date), url.QueryEscape(repeat)))
+	assert.NoError(t, err)
+	return strings.TrimSpace(string(body))
+}
+
+func getHolidays(t *testing.T) []map[string]string {
+	body, err := requestJSON("api/holidays", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]map[string]string
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m["holidays"]
+}
+
+func TestHolidays(t *testing.T) {
+	// Календарь выходных общий, менять его может только владелец сервера.
+	m, err := postJSON("api/holiday", map[string]any{"date": "20240129", "title": "Чужой выходной"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner_only", m["code"])
+	body, err := requestRaw(authToken(), "api/holidays/import", []byte("BEGIN:VCALENDAR"), "text/calendar", http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "owner_only")
+
+	env, err := godotenv.Read("../.env")
+	if err != nil || env["TODO_PASSWORD"] == "" {
+		t.Skip("нет TODO_PASSWORD в ../.env")
+	}
+	m, err = postJSON("api/signin", map[string]any{"password": env["TODO_PASSWORD"]}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["token"])
+	owner := fmt.Sprint(m["token"])
+
+	m, err = requestAs(owner, "api/holiday", map[string]any{"date": "20240129", "title": "Тестовый выходной"}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(m["id"])
+	assert.NotEmpty(t, id)
+	ids := []string{id}
+
+	for _, v := range []map[string]any{
+		{"date": "20240129", "title": "Повтор"},
+		{"date": "29.01.2024", "title": "Неверная дата"},
+	} {
+		m, err = requestAs(owner, "api/holiday", v, http.MethodPost)
+		assert.NoError(t, err)
+		e, ok := m["error"]
+		assert.False(t, !ok || len(fmt.Sprint(e)) == 0, "Ожидается ошибка для %v", v)
+	}
+
+	assert.Equal(t, "20240130", nextDateFor(t, "20240126", "b 1"))
+
+	m, err = requestAs(owner, "api/holiday", map[string]any{"id": id, "date": "20240129", "title": "Переименован"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240201",
+		"DTEND;VALUE=DATE:20240203",
+		"SUMMARY:Длинные\\, выходные",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20240129",
+		"SUMMARY:Уже есть",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Без даты",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	body, err = requestRaw(owner, "api/holidays/import", []byte(ics), "text/calendar", http.MethodPost)
+	assert.NoError(t, err)
+	var result map[string]any
+	assert.NoError(t, json.Unmarshal(body, &result))
+	assert.Equal(t, float64(2), result["added"])
+	assert.Equal(t, float64(1), result["skipped"])
+	assert.Len(t, result["errors"], 1)
+
+	body, err = requestRaw(owner, "api/holidays/import", []byte("BEGIN:VCALENDAR"), "text/calendar", http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "error")
+
+	titles := map[string]string{}
+	for _, holiday := range getHolidays(t) {
+		if holiday["date"] >= "20240129" && holiday["date"] <= "20240202" {
+			titles[holiday["date"]] = holiday["title"]
+			if holiday["id"] != id {
+				ids = append(ids, holiday["id"])
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"20240129": "Переименован",
+		"20240201": "Длинные, выходные",
+		"20240202": "Длинные, выходные",
+	}, titles)
+
+	assert.Equal(t, "20240205", nextDateFor(t, "20240126", "bm 1"))
+	assert.Equal(t, "20240205", nextDateFor(t, "20240126", "b 3"))
+
+	for _, holidayID := range ids {
+		m, err = requestAs(owner, "api/holiday?id="+holidayID, nil, http.MethodDelete)
+		assert.NoError(t, err)
+		assert.Empty(t, m)
+	}
+	m, err = requestAs(owner, "api/holiday?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m)
+
+	assert.Equal(t, "20240129", nextDateFor(t, "20240126", "b 1"))
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Yandex-Practicum/final-project/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsFresh(t *testing.T) {
+	db, err := sqlx.Connect("sqlite", filepath.Join(t.TempDir(), "fresh.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := storage.Migrations()
+	assert.NoError(t, err)
+
+	applied, err := storage.Migrate(db)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	applied, err = storage.Migrate(db)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	version, err := storage.SchemaVersion(db)
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	pending, err := storage.PendingMigrations(db)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigrationsUpgrade(t *testing.T) {
+	db, err := sqlx.Connect("sqlite", filepath.Join(t.TempDir(), "old.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+	CREATE TABLE scheduler (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date CHAR(8) NOT NULL DEFAULT "19700101",
+		title VARCHAR(128) NOT NULL DEFAULT "",
+		comment VARCHAR(256) NOT NULL DEFAULT "",
+		repeat VARCHAR(128) NOT NULL DEFAULT ""
+	);
+	CREATE INDEX date_scheduler ON scheduler (date);
+	INSERT INTO scheduler (date, title, comment, repeat) VALUES ('20240201', 'Старая задача', 'Комментарий', 'd 5');
+	`)
+	assert.NoError(t, err)
+
+	pending, err := storage.PendingMigrations(db)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pending)
+
+	_, err = storage.Migrate(db)
+	assert.NoError(t, err)
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE title = 'Старая задача'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "20240201", task.Date)
+	assert.Equal(t, "d 5", task.Repeat)
+	assert.Equal(t, int64(0), task.UserID)
+
+	var project string
+	err = db.Get(&project, `SELECT name FROM projects WHERE id = ? AND is_default = 1`, task.ProjectID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, project)
+}
+
+// БД, созданная до schema_version: часть столбцов и таблиц уже добавлена при запуске сервера.
+func TestMigrationsPreVersioned(t *testing.T) {
+	db, err := sqlx.Connect("sqlite", filepath.Join(t.TempDir(), "ensured.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+	CREATE TABLE scheduler (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date CHAR(8) NOT NULL DEFAULT "19700101",
+		title VARCHAR(128) NOT NULL DEFAULT "",
+		comment VARCHAR(256) NOT NULL DEFAULT "",
+		repeat VARCHAR(128) NOT NULL DEFAULT ""
+	);
+	CREATE INDEX date_scheduler ON scheduler (date);
+	CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, login VARCHAR(64) NOT NULL UNIQUE, password_hash VARCHAR(128) NOT NULL);
+	CREATE TABLE holidays (id INTEGER PRIMARY KEY AUTOINCREMENT, date CHAR(8) NOT NULL UNIQUE, title VARCHAR(128) NOT NULL DEFAULT "");
+	ALTER TABLE scheduler ADD COLUMN repeat_until CHAR(8) NOT NULL DEFAULT "";
+	ALTER TABLE scheduler ADD COLUMN repeat_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE scheduler ADD COLUMN time CHAR(5) NOT NULL DEFAULT "";
+	ALTER TABLE scheduler ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT "";
+	ALTER TABLE scheduler ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+	INSERT INTO users (login, password_hash) VALUES ('старый', 'hash');
+	INSERT INTO scheduler (date, title, repeat, repeat_count, time, user_id)
+		VALUES ('20240201', 'Задача пользователя', 'd 1', 3, '09:30', 1);
+	`)
+	assert.NoError(t, err)
+
+	_, err = storage.Migrate(db)
+	assert.NoError(t, err)
+	pending, err := storage.PendingMigrations(db)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE title = 'Задача пользователя'`)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), task.RepeatCount)
+	assert.Equal(t, "09:30", task.Time)
+	assert.Equal(t, int64(1), task.UserID)
+}
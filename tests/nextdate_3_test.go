@@ -74,6 +74,68 @@ func TestNextDate(t *testing.T) {
 		{"20240126", "w 7", "20240128"},
 		{"20230126", "w 4,5", "20240201"},
 		{"20230226", "w 8,4,5", ""},
+		{"20240126", "m 2:2", "20240213"},
+		{"20240101", "m -1:5", "20240223"},
+		{"20240126", "m 1:1 3,6", "20240304"},
+		{"20240126", "m 5:4", "20240229"},
+		{"20240126", "m 1:1,-1:5", "20240205"},
+		{"20240126", "m 5:5 2", "20360229"},
+		{"20240126", "m 6:1", ""},
+		{"20240126", "m 0:1", ""},
+		{"20240126", "m 2:8", ""},
+		{"20240126", "m 2:", ""},
+		{"20240126", "m 2:2 13", ""},
+		{"20240122", "w 1,3 /2", "20240205"},
+		{"20240124", "w 1 /2", "20240205"},
+		{"20240103", "w 3 /3", "20240214"},
+		{"20240126", "w 5 /1", "20240202"},
+		{"20240126", "w 1 /0", ""},
+		{"20240126", "w 1 /53", ""},
+		{"20240126", "w 1 2", ""},
+		{"20240126", "w 1 /2 /2", ""},
+		{"20231115", "m 15 /3", "20240215"},
+		{"20231115", "m -1 /2", "20240131"},
+		{"20231215", "m 1,-1 /2", "20240201"},
+		{"20231110", "m 2:2 /3", "20240213"},
+		{"20240126", "m 15 /13", ""},
+		{"20240126", "m 15 /x", ""},
+		{"20240126", "m 15 1,2 /3", ""},
+		{"20240126", "m /3", ""},
+		{"20240126", "b 1", "20240129"},
+		{"20240126", "b 5", "20240202"},
+		{"20240101", "b 10", "20240129"},
+		{"20240126", "b 0", ""},
+		{"20240126", "b x", ""},
+		{"20240126", "bm 1", "20240201"},
+		{"20240126", "bm 5", "20240207"},
+		{"20240101", "bm -1", "20240131"},
+		{"20240101", "bm 1,-1", "20240131"},
+		{"20240126", "bm 0", ""},
+		{"20240126", "bm 24", ""},
+	}
+	check()
+	tbl = []nextDate{
+		{"20240126", "FREQ=DAILY", "20240127"},
+		{"20240101", "FREQ=DAILY;INTERVAL=10", "20240131"},
+		{"20240122", "FREQ=WEEKLY;BYDAY=MO,WE;INTERVAL=2", "20240205"},
+		{"20240101", "RRULE:FREQ=MONTHLY;BYDAY=2TU", "20240213"},
+		{"20240101", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "20240131"},
+		{"20240101", "FREQ=MONTHLY;BYMONTHDAY=-1", "20240131"},
+		{"20240131", "FREQ=MONTHLY", "20240331"},
+		{"20230315", "FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=15", "20240315"},
+		{"20240229", "FREQ=YEARLY", "20280229"},
+		{"20240101", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", "20241128"},
+		{"20240120", "FREQ=DAILY;UNTIL=20240127", "20240127"},
+		{"20240120", "FREQ=DAILY;UNTIL=20240126T235959Z", ""},
+		{"20240101", "FREQ=DAILY;COUNT=3", ""},
+		{"20240120", "FREQ=WEEKLY;COUNT=2", "20240127"},
+		{"20240126", "FREQ=HOURLY", ""},
+		{"20240126", "FREQ=DAILY;BYHOUR=10", ""},
+		{"20240126", "FREQ=DAILY;INTERVAL=0", ""},
+		{"20240126", "FREQ=WEEKLY;BYDAY=XX", ""},
+		{"20240126", "FREQ=WEEKLY;BYDAY=2MO", ""},
+		{"20240126", "INTERVAL=2", ""},
+		{"20240126", "FREQ=DAILY;COUNT=2;UNTIL=20250101", ""},
 	}
 	check()
 }
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getNextDates(t *testing.T, params url.Values) map[string]any {
+	body, err := getBody("api/nextdates?" + params.Encode())
+	assert.NoError(t, err)
+	var m map[string]any
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m
+}
+
+func TestNextDates(t *testing.T) {
+	tbl := []struct {
+		date   string
+		repeat string
+		count  string
+		to     string
+		want   []any
+	}{
+		{"20240126", "d 7", "3", "", []any{"20240202", "20240209", "20240216"}},
+		{"20240126", "d 7", "", "20240210", []any{"20240202", "20240209"}},
+		{"20240126", "d 7", "5", "20240210", []any{"20240202", "20240209"}},
+		{"20240101", "m 2:2", "2", "", []any{"20240213", "20240312"}},
+		{"20240126", "FREQ=DAILY;UNTIL=20240128", "5", "", []any{"20240127", "20240128"}},
+		{"20240126", "FREQ=DAILY;COUNT=1", "5", "", []any{}},
+		{"20240126", "FREQ=DAILY;COUNT=3", "10", "", []any{"20240127", "20240128"}},
+		{"20240122", "FREQ=DAILY;COUNT=6", "10", "", []any{"20240127"}},
+		{"20240115", "RRULE:FREQ=WEEKLY;BYDAY=MO,FR;COUNT=6", "", "20241231", []any{"20240129", "20240202"}},
+	}
+	for _, v := range tbl {
+		params := url.Values{"now": {"20240126"}, "date": {v.date}, "repeat": {v.repeat}}
+		if v.count != "" {
+			params.Set("count", v.count)
+		}
+		if v.to != "" {
+			params.Set("to", v.to)
+		}
+		m := getNextDates(t, params)
+		assert.Equal(t, v.want, m["dates"], "%v", v)
+
+		if len(v.want) == 0 {
+			continue
+		}
+		first, err := getBody(fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat)))
+		assert.NoError(t, err)
+		assert.Equal(t, v.want[0], strings.TrimSpace(string(first)))
+	}
+
+	for _, params := range []url.Values{
+		{"now": {"20240126"}, "date": {"20240126"}, "repeat": {"d 7"}},
+		{"now": {"20240126"}, "date": {"20240126"}, "repeat": {"d 7"}, "count": {"abc"}},
+		{"now": {"20240126"}, "date": {"20240126"}, "repeat": {"d 7"}, "count": {"1000"}},
+		{"now": {"20240126"}, "date": {"20240126"}, "repeat": {"d 7"}, "to": {"10.02.2024"}},
+		{"now": {"20240126"}, "date": {"20240126"}, "repeat": {"ooops"}, "count": {"3"}},
+		{"date": {"20240126"}, "repeat": {"d 7"}, "count": {"3"}},
+	} {
+		m := getNextDates(t, params)
+		e, ok := m["error"]
+		assert.False(t, !ok || len(fmt.Sprint(e)) == 0, "Ожидается ошибка для %v", params)
+	}
+}
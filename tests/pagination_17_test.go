@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func taskPage(t *testing.T, token string, params url.Values) ([]string, string) {
+	m, err := requestAs(token, "api/tasks?"+params.Encode(), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"], params.Encode())
+
+	var titles []string
+	tasks, _ := m["tasks"].([]any)
+	for _, v := range tasks {
+		titles = append(titles, fmt.Sprint(v.(map[string]any)["title"]))
+	}
+	next, _ := m["next_cursor"].(string)
+	return titles, next
+}
+
+func TestTasksPagination(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("pages%d", time.Now().UnixNano()), "secret1")
+	now := time.Now()
+
+	for i, v := range []struct {
+		title  string
+		repeat string
+	}{
+		{"Б", ""},
+		{"Д", "d 7"},
+		{"А", ""},
+		{"Г", "d 10"},
+		{"В", ""},
+	} {
+		m, err := requestAs(token, "api/task", map[string]any{
+			"date":   now.AddDate(0, 0, i+1).Format(`20060102`),
+			"title":  v.title,
+			"repeat": v.repeat,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotNil(t, m["id"])
+	}
+
+	var all []string
+	params := url.Values{"limit": {"2"}}
+	for page := 0; page < 5; page++ {
+		titles, next := taskPage(t, token, params)
+		assert.LessOrEqual(t, len(titles), 2)
+		all = append(all, titles...)
+		if next == "" {
+			break
+		}
+		params.Set("cursor", next)
+	}
+	assert.Equal(t, []string{"В", "Г", "А", "Д", "Б"}, all)
+
+	titles, next := taskPage(t, token, url.Values{})
+	assert.Equal(t, []string{"В", "Г", "А", "Д", "Б"}, titles)
+	assert.Empty(t, next)
+
+	titles, next = taskPage(t, token, url.Values{"sort": {"title"}, "order": {"asc"}, "limit": {"3"}})
+	assert.Equal(t, []string{"А", "Б", "В"}, titles)
+	titles, next = taskPage(t, token, url.Values{"sort": {"title"}, "order": {"asc"}, "limit": {"3"}, "cursor": {next}})
+	assert.Equal(t, []string{"Г", "Д"}, titles)
+	assert.Empty(t, next)
+
+	titles, _ = taskPage(t, token, url.Values{
+		"from":  {now.AddDate(0, 0, 2).Format(`20060102`)},
+		"to":    {now.AddDate(0, 0, 4).Format(`20060102`)},
+		"order": {"asc"},
+	})
+	assert.Equal(t, []string{"Д", "А", "Г"}, titles)
+
+	titles, _ = taskPage(t, token, url.Values{"has_repeat": {"true"}})
+	assert.Equal(t, []string{"Г", "Д"}, titles)
+	titles, _ = taskPage(t, token, url.Values{"has_repeat": {"false"}})
+	assert.Equal(t, []string{"В", "А", "Б"}, titles)
+
+	_, next = taskPage(t, token, url.Values{"limit": {"1"}})
+	assert.NotEmpty(t, next)
+	for _, params := range []url.Values{
+		{"sort": {"rank"}},
+		{"order": {"up"}},
+		{"limit": {"0"}},
+		{"has_repeat": {"maybe"}},
+		{"from": {"2024-01-01"}},
+		{"cursor": {"garbage"}},
+		{"cursor": {next}, "sort": {"title"}},
+	} {
+		m, err := requestAs(token, "api/tasks?"+params.Encode(), nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %s", params.Encode())
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("priority%d", time.Now().UnixNano()), "secret1")
+	today := time.Now().Format(`20060102`)
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+
+	add := func(title, date, priority string) string {
+		values := map[string]any{"title": title, "date": date}
+		if priority != "" {
+			values["priority"] = priority
+		}
+		m, err := requestAs(token, "api/task", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Nil(t, m["error"])
+		return fmt.Sprint(m["id"])
+	}
+	add("Обычная сегодня", today, "")
+	urgent := add("Срочная сегодня", today, "urgent")
+	add("Низкая сегодня", today, "low")
+	add("Важная завтра", tomorrow, "HIGH")
+
+	m, err := requestAs(token, "api/task?id="+urgent, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "urgent", m["priority"])
+
+	titles, _ := taskPage(t, token, url.Values{"order": {"asc"}})
+	assert.Equal(t, []string{"Срочная сегодня", "Обычная сегодня", "Низкая сегодня", "Важная завтра"}, titles)
+
+	titles, _ = taskPage(t, token, url.Values{"sort": {"priority"}})
+	assert.Equal(t, []string{"Срочная сегодня", "Важная завтра", "Обычная сегодня", "Низкая сегодня"}, titles)
+
+	var all []string
+	params := url.Values{"sort": {"priority"}, "limit": {"1"}}
+	for page := 0; page < 5; page++ {
+		titles, next := taskPage(t, token, params)
+		all = append(all, titles...)
+		if next == "" {
+			break
+		}
+		params.Set("cursor", next)
+	}
+	assert.Equal(t, []string{"Срочная сегодня", "Важная завтра", "Обычная сегодня", "Низкая сегодня"}, all)
+
+	titles, _ = taskPage(t, token, url.Values{"priority": {"high,urgent"}, "order": {"asc"}})
+	assert.Equal(t, []string{"Срочная сегодня", "Важная завтра"}, titles)
+
+	m, err = requestAs(token, "api/task", map[string]any{"id": urgent, "title": "Срочная сегодня", "date": today}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task?id="+urgent, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "urgent", m["priority"])
+
+	m, err = requestAs(token, "api/task", map[string]any{
+		"id": urgent, "title": "Срочная сегодня", "date": today, "priority": "low",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task?id="+urgent, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "low", m["priority"])
+
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Ошибка", "priority": "critical"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(token, "api/tasks?priority=critical", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+}
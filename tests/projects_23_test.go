@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjects(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("projects%d", time.Now().UnixNano()), "secret1")
+	projects := func(params string) []map[string]any {
+		m, err := requestAs(token, "api/projects"+params, nil, http.MethodGet)
+		assert.NoError(t, err)
+		var projects []map[string]any
+		list, _ := m["projects"].([]any)
+		for _, v := range list {
+			projects = append(projects, v.(map[string]any))
+		}
+		return projects
+	}
+
+	list := projects("")
+	if !assert.Len(t, list, 1) {
+		return
+	}
+	assert.Equal(t, true, list[0]["default"])
+	inbox := fmt.Sprint(list[0]["id"])
+
+	m, err := requestAs(token, "api/project", map[string]any{"name": "Работа", "color": "#FF8800"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"])
+	work := fmt.Sprint(m["id"])
+
+	m, err = requestAs(token, "api/project?id="+work, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Работа", m["name"])
+	assert.Equal(t, "#ff8800", m["color"])
+
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Личное"}, http.MethodPost)
+	assert.NoError(t, err)
+	personal := fmt.Sprint(m["id"])
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Дежурство", "project_id": work}, http.MethodPost)
+	assert.NoError(t, err)
+	duty := fmt.Sprint(m["id"])
+
+	m, err = requestAs(token, "api/task?id="+personal, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, inbox, m["project_id"])
+
+	titles, _ := taskPage(t, token, url.Values{"project_id": {work}})
+	assert.Equal(t, []string{"Дежурство"}, titles)
+
+	m, err = requestAs(token, "api/task/move?id="+personal+"&project_id="+work, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	titles, _ = taskPage(t, token, url.Values{"project_id": {work}, "sort": {"title"}, "order": {"asc"}})
+	assert.Equal(t, []string{"Дежурство", "Личное"}, titles)
+
+	m, err = requestAs(token, "api/task", map[string]any{"id": duty, "title": "Дежурство"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task?id="+duty, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, work, m["project_id"])
+
+	m, err = requestAs(token, "api/project", map[string]any{"id": work, "name": "Работа", "archived": true}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Len(t, projects(""), 1)
+	assert.Len(t, projects("?archived=true"), 2)
+	titles, _ = taskPage(t, token, url.Values{})
+	assert.Empty(t, titles)
+	titles, _ = taskPage(t, token, url.Values{"project_id": {work}})
+	assert.Len(t, titles, 2)
+	m, err = requestAs(token, "api/task", map[string]any{"title": "В архив", "project_id": work}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(token, "api/project?id="+work, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	titles, _ = taskPage(t, token, url.Values{"project_id": {inbox}})
+	assert.Len(t, titles, 2)
+
+	other := signUp(t, fmt.Sprintf("projectsother%d", time.Now().UnixNano()), "secret1")
+	m, err = requestAs(other, "api/project?id="+inbox, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(other, "api/task", map[string]any{"title": "Чужой проект", "project_id": inbox}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	for _, request := range []struct {
+		values map[string]any
+		method string
+	}{
+		{map[string]any{"name": ""}, http.MethodPost},
+		{map[string]any{"name": "Цвет", "color": "red"}, http.MethodPost},
+		{map[string]any{"name": "Входящие"}, http.MethodPost},
+		{map[string]any{"id": inbox, "name": "Входящие", "archived": true}, http.MethodPut},
+	} {
+		m, err = requestAs(token, "api/project", request.values, request.method)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %v", request.values)
+	}
+	m, err = requestAs(token, "api/project?id="+inbox, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+}
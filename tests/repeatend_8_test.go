@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepeatEnd(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	today := now.Format(`20060102`)
+
+	for _, v := range []map[string]any{
+		{"date": today, "title": "Без правила", "repeat_count": 3},
+		{"date": today, "title": "Без правила", "repeat_until": "20991231"},
+		{"date": today, "title": "Неверная дата", "repeat": "d 1", "repeat_until": "31.12.2099"},
+		{"date": today, "title": "Оба условия", "repeat": "d 1", "repeat_count": 2, "repeat_until": "20991231"},
+		{"date": today, "title": "Отрицательное число", "repeat": "d 1", "repeat_count": -1},
+		{"date": now.AddDate(0, 0, 5).Format(`20060102`), "title": "Поздно", "repeat": "d 1", "repeat_until": today},
+	} {
+		m, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		e, ok := m["error"]
+		assert.False(t, !ok || len(fmt.Sprint(e)) == 0, "Ожидается ошибка для задачи %v", v)
+	}
+
+	m, err := postJSON("api/task", map[string]any{
+		"date":         today,
+		"title":        "Три раза",
+		"repeat":       "d 2",
+		"repeat_count": 3,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(m["id"])
+
+	for i := 3; i > 1; i-- {
+		var stored Task
+		err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), stored.RepeatCount)
+
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	var stored Task
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stored.RepeatCount)
+	assert.Equal(t, now.AddDate(0, 0, 4).Format(`20060102`), stored.Date)
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	m, err = postJSON("api/task", map[string]any{
+		"date":         today,
+		"title":        "До конца недели",
+		"repeat":       "d 3",
+		"repeat_until": now.AddDate(0, 0, 5).Format(`20060102`),
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(m["id"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), stored.Date)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	id = addTask(t, task{
+		date:   today,
+		title:  "Единственный раз по RRULE",
+		repeat: "FREQ=DAILY;COUNT=1",
+	})
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	id = addTask(t, task{
+		date:   today,
+		title:  "Три раза по RRULE",
+		repeat: "FREQ=DAILY;COUNT=3",
+	})
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=DAILY", stored.Repeat)
+	assert.Equal(t, int64(3), stored.RepeatCount)
+	for i := 1; i < 3; i++ {
+		ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+		err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, i).Format(`20060102`), stored.Date)
+	}
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	m, err = postJSON("api/task", map[string]any{
+		"date":         today,
+		"title":        "COUNT и repeat_count",
+		"repeat":       "FREQ=DAILY;COUNT=3",
+		"repeat_count": 2,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	// PUT без полей окончания оставляет его прежним, явный 0 делает повторение бесконечным.
+	m, err = postJSON("api/task", map[string]any{
+		"date":         today,
+		"title":        "Пять раз",
+		"repeat":       "d 1",
+		"repeat_count": 5,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(m["id"])
+	for _, v := range []struct {
+		update map[string]any
+		count  int64
+	}{
+		{map[string]any{"id": id, "date": today, "title": "Пять раз", "comment": "", "repeat": "d 1"}, 5},
+		{map[string]any{"id": id, "date": today, "title": "Пять раз", "repeat": "d 2"}, 5},
+		{map[string]any{"id": id, "date": today, "title": "Пять раз", "repeat": "d 2", "repeat_count": 0}, 0},
+	} {
+		ret, err = postJSON("api/task", v.update, http.MethodPut)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+		err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, v.count, stored.RepeatCount, "%v", v.update)
+	}
+
+	until := now.AddDate(0, 0, 5).Format(`20060102`)
+	m, err = postJSON("api/task", map[string]any{
+		"date":         today,
+		"title":        "До даты",
+		"repeat":       "d 1",
+		"repeat_until": until,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(m["id"])
+	ret, err = postJSON("api/task", map[string]any{"id": id, "date": today, "title": "До даты", "repeat": "d 1"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, until, stored.RepeatUntil)
+	ret, err = postJSON("api/task", map[string]any{"id": id, "date": today, "title": "Один раз"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Empty(t, stored.RepeatUntil)
+}
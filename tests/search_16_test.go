@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func searchTitles(t *testing.T, search string) []string {
+	var titles []string
+	for _, task := range getTasks(t, url.QueryEscape(search)) {
+		titles = append(titles, task["title"])
+	}
+	return titles
+}
+
+func TestFullTextSearch(t *testing.T) {
+	if !Search {
+		return
+	}
+	tag := fmt.Sprintf("метка%d", time.Now().UnixNano())
+	today := time.Now().Format(`20060102`)
+
+	addTask(t, task{date: today, title: "Купить молоко " + tag, comment: "обязательно свежее"})
+	addTask(t, task{date: today, title: "Молоко " + tag, comment: "молоко"})
+	addTask(t, task{date: today, title: "Позвонить маме " + tag, comment: "про молочные продукты"})
+	id := addTask(t, task{date: today, title: "Свежий хлеб " + tag})
+
+	// Релевантность зависит от доли задач со словом, поэтому добавляем
+	// задачи без него, чтобы ранжирование не зависело от содержимого БД.
+	var fillers []string
+	for i := 0; i < 6; i++ {
+		fillers = append(fillers, addTask(t, task{date: today, title: fmt.Sprintf("Разное %d", i)}))
+	}
+	defer func() {
+		for _, filler := range fillers {
+			_, err := postJSON("api/task?id="+filler, nil, http.MethodDelete)
+			assert.NoError(t, err)
+		}
+	}()
+
+	assert.Len(t, searchTitles(t, tag), 4)
+
+	found := getTasks(t, url.QueryEscape(tag+" молоко"))
+	if assert.Len(t, found, 2) {
+		assert.Equal(t, "Молоко "+tag, found[0]["title"])
+		assert.True(t, strings.Contains(found[0]["snippet"], "<mark>"), found[0]["snippet"])
+	}
+
+	// Текст задачи во фрагменте экранируется, размечены только совпадения.
+	htmlTag := "разметка" + tag
+	addTask(t, task{date: today, title: `<img src=x onerror="alert(1)"> ` + htmlTag})
+	found = getTasks(t, url.QueryEscape(htmlTag))
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>"+htmlTag+"</mark>", found[0]["snippet"])
+	}
+
+	assert.Equal(t, []string{"Купить молоко " + tag}, searchTitles(t, `"купить молоко" `+tag))
+	assert.Equal(t, []string{"Позвонить маме " + tag}, searchTitles(t, "молоч* "+tag))
+	assert.Len(t, searchTitles(t, tag+" AND (хлеб OR маме)"), 2)
+	assert.Len(t, searchTitles(t, tag+" NOT молоко"), 2)
+	assert.Len(t, searchTitles(t, "("+tag), 4)
+	assert.Len(t, searchTitles(t, tag+" 18:00"), 0)
+
+	ret, err := postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  today,
+		"title": "Свежий батон " + tag,
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Len(t, searchTitles(t, tag+" хлеб"), 0)
+	assert.Len(t, searchTitles(t, tag+" батон"), 1)
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Len(t, searchTitles(t, tag+" батон"), 0)
+}
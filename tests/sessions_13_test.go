@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	login := fmt.Sprint("session", time.Now().UnixNano())
+	m, err := postJSON("api/signup", map[string]any{"login": login, "password": "secret1"}, http.MethodPost)
+	assert.NoError(t, err)
+	token := fmt.Sprint(m["token"])
+	refresh := fmt.Sprint(m["refresh_token"])
+	assert.NotEmpty(t, refresh)
+
+	m, err = requestAs(token, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotNil(t, m["tasks"])
+
+	m, err = postJSON("api/refresh", map[string]any{"refresh_token": refresh}, http.MethodPost)
+	assert.NoError(t, err)
+	newToken := fmt.Sprint(m["token"])
+	newRefresh := fmt.Sprint(m["refresh_token"])
+	assert.NotEqual(t, token, newToken)
+	assert.NotEqual(t, refresh, newRefresh)
+
+	m, err = requestAs(newToken, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotNil(t, m["tasks"])
+
+	m, err = postJSON("api/refresh", map[string]any{"refresh_token": "garbage"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	// повторное использование старого refresh-токена отзывает всю сессию
+	m, err = postJSON("api/refresh", map[string]any{"refresh_token": refresh}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(newToken, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_revoked", m["code"])
+	m, err = postJSON("api/refresh", map[string]any{"refresh_token": newRefresh}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = postJSON("api/signin", map[string]any{"login": login, "password": "secret1"}, http.MethodPost)
+	assert.NoError(t, err)
+	token = fmt.Sprint(m["token"])
+	refresh = fmt.Sprint(m["refresh_token"])
+
+	m, err = requestAs(token, "api/signout", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	m, err = requestAs(token, "api/tasks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_revoked", m["code"])
+	m, err = postJSON("api/refresh", map[string]any{"refresh_token": refresh}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+}
+
+// Токен со своей сессией, но чужим uid отклоняется, даже если подписан верным ключом.
+func TestSessionOwner(t *testing.T) {
+	env, err := godotenv.Read("../.env")
+	if err != nil || env["SECRET_KEY"] == "" {
+		t.Skip("нет SECRET_KEY в ../.env")
+	}
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	victim := signUp(t, "victim"+suffix, "secret1")
+	attacker := signUp(t, "attacker"+suffix, "secret1")
+	m, err := requestAs(victim, "api/task", map[string]any{"title": "Чужая задача"}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(m["id"])
+
+	claims := func(token string) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+		assert.NoError(t, err)
+		return claims
+	}
+	forged := claims(attacker)
+	forged["uid"] = claims(victim)["uid"]
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, forged).SignedString([]byte(env["SECRET_KEY"]))
+	assert.NoError(t, err)
+
+	m, err = requestAs(token, "api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "token_revoked", m["code"])
+	m, err = requestAs(victim, "api/task?id="+taskID, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, "Чужая задача", m["title"])
+}
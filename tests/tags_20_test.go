@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("tags%d", time.Now().UnixNano()), "secret1")
+	add := func(title string, tags []string) string {
+		values := map[string]any{"title": title}
+		if tags != nil {
+			values["tags"] = tags
+		}
+		m, err := requestAs(token, "api/task", values, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Nil(t, m["error"])
+		return fmt.Sprint(m["id"])
+	}
+	tagsOf := func(id string) []any {
+		m, err := requestAs(token, "api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		tags, _ := m["tags"].([]any)
+		return tags
+	}
+
+	deploy := add("Выкатить релиз", []string{"ops", "#Release", " ops "})
+	backup := add("Проверить бэкапы", []string{"ops"})
+	add("Купить продукты", []string{"дом"})
+	add("Без тегов", nil)
+
+	assert.Equal(t, []any{"ops", "release"}, tagsOf(deploy))
+
+	titles, _ := taskPage(t, token, url.Values{"tags": {"ops"}, "sort": {"title"}, "order": {"asc"}})
+	assert.Equal(t, []string{"Выкатить релиз", "Проверить бэкапы"}, titles)
+	titles, _ = taskPage(t, token, url.Values{"tags": {"ops,release"}, "tags_mode": {"and"}})
+	assert.Equal(t, []string{"Выкатить релиз"}, titles)
+	titles, _ = taskPage(t, token, url.Values{"tags": {"release", "дом"}, "sort": {"title"}, "order": {"asc"}})
+	assert.Equal(t, []string{"Выкатить релиз", "Купить продукты"}, titles)
+	titles, _ = taskPage(t, token, url.Values{"tags": {"нет-такого"}})
+	assert.Empty(t, titles)
+
+	m, err := requestAs(token, "api/task", map[string]any{"id": backup, "title": "Проверить бэкапы"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Equal(t, []any{"ops"}, tagsOf(backup))
+
+	m, err = requestAs(token, "api/task", map[string]any{
+		"id": backup, "title": "Проверить бэкапы", "tags": []string{},
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Empty(t, tagsOf(backup))
+
+	m, err = requestAs(token, "api/task/done?id="+deploy, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task/undone?id="+deploy, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, m["error"])
+	assert.Equal(t, []any{"ops", "release"}, tagsOf(deploy))
+
+	other := signUp(t, fmt.Sprintf("tagsother%d", time.Now().UnixNano()), "secret1")
+	mine, _ := taskPage(t, other, url.Values{"tags": {"ops"}})
+	assert.Empty(t, mine)
+
+	for _, tags := range [][]string{{""}, {"a,b"}, {"очень-длинный-тег-который-не-помещается-в-лимит"}} {
+		m, err = requestAs(token, "api/task", map[string]any{"title": "Ошибка", "tags": tags}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %v", tags)
+	}
+	m, err = requestAs(token, "api/tasks?tags=ops&tags_mode=xor", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTime(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	today := time.Now().Format(`20060102`)
+	for _, v := range []map[string]any{
+		{"date": today, "title": "Неверное время", "time": "25:00"},
+		{"date": today, "title": "Неверное время", "time": "9:00"},
+		{"date": today, "title": "Неверный пояс", "timezone": "Mars/Olympus"},
+	} {
+		m, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		e, ok := m["error"]
+		assert.False(t, !ok || len(fmt.Sprint(e)) == 0, "Ожидается ошибка для задачи %v", v)
+	}
+
+	m, err := postJSON("api/task", map[string]any{
+		"date":     today,
+		"title":    "Созвон",
+		"time":     "16:00",
+		"timezone": "Europe/Moscow",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(m["id"])
+
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var withTime map[string]string
+	assert.NoError(t, json.Unmarshal(body, &withTime))
+	assert.Equal(t, "16:00", withTime["time"])
+	assert.Equal(t, "Europe/Moscow", withTime["timezone"])
+
+	// Клиент, не знающий о времени, не должен его сбрасывать.
+	m, err = postJSON("api/task", map[string]any{
+		"id":      id,
+		"date":    withTime["date"],
+		"title":   "Созвон перенесён",
+		"comment": "",
+		"repeat":  "",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	withTime = nil
+	assert.NoError(t, json.Unmarshal(body, &withTime))
+	assert.Equal(t, "Созвон перенесён", withTime["title"])
+	assert.Equal(t, "16:00", withTime["time"])
+	assert.Equal(t, "Europe/Moscow", withTime["timezone"])
+
+	m, err = postJSON("api/task", map[string]any{
+		"id":    id,
+		"date":  withTime["date"],
+		"title": "Созвон перенесён",
+		"time":  "",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	withTime = nil
+	assert.NoError(t, json.Unmarshal(body, &withTime))
+	assert.NotContains(t, withTime, "time")
+	assert.Equal(t, "Europe/Moscow", withTime["timezone"])
+
+	id = addTask(t, task{date: today, title: "Только дата"})
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var dateOnly map[string]any
+	assert.NoError(t, json.Unmarshal(body, &dateOnly))
+	assert.NotContains(t, dateOnly, "time")
+	assert.NotContains(t, dateOnly, "timezone")
+
+	for _, zone := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		loc, err := time.LoadLocation(zone)
+		assert.NoError(t, err)
+		zoneToday := time.Now().In(loc).Format(`20060102`)
+
+		m, err := postJSON("api/task", map[string]any{
+			"date":     zoneToday,
+			"title":    "Сегодня в " + zone,
+			"timezone": zone,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+
+		var stored Task
+		err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, fmt.Sprint(m["id"]))
+		assert.NoError(t, err)
+		assert.Equal(t, zoneToday, stored.Date)
+		assert.Equal(t, zone, stored.Timezone)
+
+		m, err = postJSON("api/task", map[string]any{
+			"date":     "20240101",
+			"title":    "Просрочено в " + zone,
+			"timezone": zone,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, fmt.Sprint(m["id"]))
+		assert.NoError(t, err)
+		assert.Equal(t, zoneToday, stored.Date)
+	}
+}
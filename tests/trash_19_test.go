@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	token := signUp(t, "trash"+suffix, "secret1")
+	titles := func(path string) []string {
+		m, err := requestAs(token, path, nil, http.MethodGet)
+		assert.NoError(t, err)
+		var titles []string
+		tasks, _ := m["tasks"].([]any)
+		for _, v := range tasks {
+			titles = append(titles, fmt.Sprint(v.(map[string]any)["title"]))
+		}
+		return titles
+	}
+	add := func(title string) string {
+		m, err := requestAs(token, "api/task", map[string]any{"title": title}, http.MethodPost)
+		assert.NoError(t, err)
+		return fmt.Sprint(m["id"])
+	}
+
+	first := add("Первая " + suffix)
+	second := add("Вторая " + suffix)
+
+	m, err := requestAs(token, "api/task?id="+first, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Equal(t, []string{"Вторая " + suffix}, titles("api/tasks"))
+	assert.Equal(t, []string{"Вторая " + suffix}, titles("api/tasks?search="+suffix))
+	assert.Equal(t, []string{"Первая " + suffix}, titles("api/trash"))
+
+	m, err = requestAs(token, "api/task?id="+first, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(token, "api/task", map[string]any{"id": first, "title": "Правка"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(token, "api/task?id="+first, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(token, "api/trash/restore?id="+first, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	assert.Len(t, titles("api/tasks"), 2)
+	assert.Empty(t, titles("api/trash"))
+	m, err = requestAs(token, "api/trash/restore?id="+first, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	for _, id := range []string{first, second} {
+		_, err = requestAs(token, "api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, titles("api/trash"), 2)
+
+	m, err = requestAs(token, "api/trash?id="+first, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), m["purged"])
+	m, err = requestAs(token, "api/trash/restore?id="+first, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(token, "api/trash", nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), m["purged"])
+	assert.Empty(t, titles("api/trash"))
+
+	old := add("Давно удалённая " + suffix)
+	_, err = requestAs(token, "api/task?id="+old, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	_, err = db.Exec(`UPDATE scheduler SET deleted_at = ? WHERE id = ?`,
+		time.Now().UTC().AddDate(-1, 0, 0).Format(time.RFC3339), old)
+	assert.NoError(t, err)
+	assert.Empty(t, titles("api/trash"))
+
+	var left int
+	err = db.Get(&left, `SELECT count(*) FROM scheduler WHERE id = ?`, old)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, left)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func requestAs(token string, apipath string, values map[string]any, method string) (map[string]any, error) {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	jar.SetCookies(req.URL, []*http.Cookie{{Name: "token", Value: token}})
+	client := &http.Client{Jar: jar}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]any
+	err = json.Unmarshal(body, &m)
+	return m, err
+}
+
+func signUp(t *testing.T, login, password string) string {
+	m, err := postJSON("api/signup", map[string]any{"login": login, "password": password}, http.MethodPost)
+	assert.NoError(t, err)
+	token, ok := m["token"].(string)
+	assert.True(t, ok, "Не возвращён токен для %s: %v", login, m)
+	return token
+}
+
+func TestUsers(t *testing.T) {
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	alice := signUp(t, "alice"+suffix, "secret1")
+	bob := signUp(t, "bob"+suffix, "secret2")
+
+	for _, v := range []map[string]any{
+		{"login": "alice" + suffix, "password": "another"},
+		{"login": "ab", "password": "secret1"},
+		{"login": "carol" + suffix, "password": "123"},
+	} {
+		m, err := postJSON("api/signup", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %v", v)
+	}
+
+	m, err := postJSON("api/signin", map[string]any{"login": "alice" + suffix, "password": "wrong"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = postJSON("api/signin", map[string]any{"login": "alice" + suffix, "password": "secret1"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["token"])
+
+	title := "Задача Алисы " + suffix
+	m, err = requestAs(alice, "api/task", map[string]any{"title": title}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(m["id"])
+
+	m, err = requestAs(alice, "api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, title, m["title"])
+
+	m, err = requestAs(alice, "api/tasks?search="+suffix, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, m["tasks"], 1)
+	m, err = requestAs(bob, "api/tasks?search="+suffix, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Empty(t, m["tasks"])
+	assert.Empty(t, getTasks(t, suffix))
+
+	m, err = requestAs(bob, "api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(bob, "api/task", map[string]any{"id": id, "title": "Чужая"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(bob, "api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+	m, err = requestAs(bob, "api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(alice, "api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+}
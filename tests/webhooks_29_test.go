@@ -0,0 +1,262 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookCall struct {
+	event     string
+	delivery  string
+	signature string
+	body      []byte
+}
+
+func TestWebhooks(t *testing.T) {
+	token := signUp(t, fmt.Sprintf("hooks%d", time.Now().UnixNano()), "secret1")
+
+	calls := make(chan webhookCall, 10)
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls <- webhookCall{
+			event:     r.Header.Get("X-Webhook-Event"),
+			delivery:  r.Header.Get("X-Webhook-Delivery"),
+			signature: r.Header.Get("X-Webhook-Signature"),
+			body:      body,
+		}
+		if received.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer receiver.Close()
+
+	next := func() webhookCall {
+		select {
+		case call := <-calls:
+			return call
+		case <-time.After(5 * time.Second):
+			t.Fatal("событие не доставлено")
+			return webhookCall{}
+		}
+	}
+
+	if allow, _ := strconv.ParseBool(os.Getenv("TODO_WEBHOOK_ALLOW_PRIVATE")); !allow {
+		m, err := requestAs(token, "api/webhook", map[string]any{"url": receiver.URL}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"])
+		t.Skip("для доставки на локальный адрес запустите сервер с TODO_WEBHOOK_ALLOW_PRIVATE=true")
+	}
+
+	for _, v := range []map[string]any{
+		{"url": "ftp://example.com/hook"},
+		{"url": "example.com"},
+		{"url": receiver.URL, "events": []string{"task.unknown"}},
+	} {
+		m, err := requestAs(token, "api/webhook", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для %v", v)
+	}
+
+	m, err := requestAs(token, "api/webhook", map[string]any{
+		"url":    receiver.URL,
+		"secret": "s3cret",
+		"events": []string{"task.created", "task.completed"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", m["secret"])
+	webhookID := fmt.Sprint(m["id"])
+
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Полить цветы"}, http.MethodPost)
+	assert.NoError(t, err)
+	taskID := fmt.Sprint(m["id"])
+
+	call := next()
+	assert.Equal(t, "task.created", call.event)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(call.body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), call.signature)
+	var payload struct {
+		Event string `json:"event"`
+		Data  struct {
+			Task map[string]any `json:"task"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(call.body, &payload))
+	assert.Equal(t, "task.created", payload.Event)
+	assert.Equal(t, taskID, payload.Data.Task["id"])
+	assert.Equal(t, "Полить цветы", payload.Data.Task["title"])
+
+	delivery := func(id, status string) map[string]any {
+		var last map[string]any
+		for i := 0; i < 50; i++ {
+			m, err := requestAs(token, "api/webhooks/deliveries?webhook_id="+webhookID, nil, http.MethodGet)
+			assert.NoError(t, err)
+			deliveries, _ := m["deliveries"].([]any)
+			for _, v := range deliveries {
+				if d := v.(map[string]any); fmt.Sprint(d["id"]) == id {
+					last = d
+				}
+			}
+			if last != nil && last["status"] == status {
+				return last
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatalf("доставка не перешла в состояние %s: %v", status, last)
+		return nil
+	}
+
+	d := delivery(call.delivery, "pending")
+	for d["response_code"] == nil {
+		d = delivery(call.delivery, "pending")
+	}
+	assert.Equal(t, float64(1), d["attempts"])
+	assert.Equal(t, float64(500), d["response_code"])
+	assert.NotEmpty(t, d["error"])
+
+	// Пока первое событие ждёт повтора, следующие события подписки не отправляются.
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Полить кактус"}, http.MethodPost)
+	assert.NoError(t, err)
+	secondID := fmt.Sprint(m["id"])
+	select {
+	case early := <-calls:
+		t.Fatalf("событие отправлено раньше предыдущего: %s", early.body)
+	case <-time.After(2 * time.Second):
+	}
+
+	m, err = requestAs(token, "api/webhooks/deliveries/retry?id="+call.delivery, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	first := call.delivery
+	call = next()
+	assert.Equal(t, first, call.delivery)
+	assert.Equal(t, "task.created", call.event)
+	second := next()
+	var secondPayload struct {
+		Data struct {
+			Task map[string]any `json:"task"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(second.body, &secondPayload))
+	assert.Equal(t, secondID, secondPayload.Data.Task["id"])
+	delivery(second.delivery, "delivered")
+	d = delivery(call.delivery, "delivered")
+	assert.Equal(t, float64(2), d["attempts"])
+	assert.Equal(t, float64(200), d["response_code"])
+	assert.NotEmpty(t, d["delivered_at"])
+
+	m, err = requestAs(token, "api/webhooks/deliveries/retry?id="+call.delivery, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m["error"])
+
+	m, err = requestAs(token, "api/task", map[string]any{"id": taskID, "title": "Полить все цветы"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/task/done?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+
+	call = next()
+	assert.Equal(t, "task.completed", call.event)
+	assert.NoError(t, json.Unmarshal(call.body, &payload))
+	assert.Equal(t, "Полить все цветы", payload.Data.Task["title"])
+
+	m, err = requestAs(token, "api/webhooks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	if assert.Len(t, m["webhooks"], 1) {
+		webhook := m["webhooks"].([]any)[0].(map[string]any)
+		assert.Equal(t, receiver.URL, webhook["url"])
+		assert.Nil(t, webhook["secret"])
+	}
+
+	m, err = requestAs(token, "api/webhook?id="+webhookID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	m, err = requestAs(token, "api/webhooks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Empty(t, m["webhooks"])
+
+	m, err = requestAs(token, "api/task", map[string]any{"title": "Без подписки"}, http.MethodPost)
+	assert.NoError(t, err)
+	otherID := fmt.Sprint(m["id"])
+	select {
+	case call := <-calls:
+		t.Errorf("лишнее событие %s после удаления подписки", call.event)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	// Отмена выполнения, восстановление из корзины, чек-лист и зависимости
+	// тоже сообщают об изменении задачи.
+	m, err = requestAs(token, "api/webhook", map[string]any{"url": receiver.URL}, http.MethodPost)
+	assert.NoError(t, err)
+	expect := func(event string) map[string]any {
+		call := next()
+		assert.Equal(t, event, call.event)
+		var body struct {
+			Data struct {
+				Task map[string]any `json:"task"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(call.body, &body))
+		assert.Equal(t, taskID, body.Data.Task["id"])
+		return body.Data.Task
+	}
+
+	_, err = requestAs(token, "api/task/undone?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	expect("task.created")
+
+	m, err = requestAs(token, "api/task/checklist", map[string]any{"task_id": taskID, "title": "Фикус"}, http.MethodPost)
+	assert.NoError(t, err)
+	itemID := fmt.Sprint(m["id"])
+	assert.Len(t, expect("task.updated")["checklist"], 1)
+	_, err = requestAs(token, "api/task/checklist/check?id="+itemID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	task := expect("task.updated")
+	if checklist, ok := task["checklist"].([]any); assert.True(t, ok) && assert.Len(t, checklist, 1) {
+		assert.Equal(t, true, checklist[0].(map[string]any)["done"])
+	}
+	_, err = requestAs(token, "api/task/checklist?id="+itemID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, expect("task.updated")["checklist"])
+
+	dependency := map[string]any{"task_id": taskID, "depends_on": otherID}
+	_, err = requestAs(token, "api/task/dependency", dependency, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Len(t, expect("task.updated")["blocked_by"], 1)
+	_, err = requestAs(token, "api/task/dependency?task_id="+taskID+"&depends_on="+otherID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, expect("task.updated")["blocked_by"])
+
+	_, err = requestAs(token, "api/task?id="+taskID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	expect("task.deleted")
+	_, err = requestAs(token, "api/trash/restore?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	expect("task.created")
+
+	m, err = requestAs(token, "api/task", map[string]any{"id": taskID, "title": "Поливать цветы", "repeat": "d 2"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+	expect("task.updated")
+	_, err = requestAs(token, "api/task/done?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	expect("task.completed")
+	_, err = requestAs(token, "api/task/undone?id="+taskID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	expect("task.updated")
+}